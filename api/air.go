@@ -0,0 +1,63 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// DefaultAirQualityURL is the base URL of the public Open-Meteo air quality
+// API
+const DefaultAirQualityURL = "https://air-quality-api.open-meteo.com"
+
+// AirQualityVariables are the current variables requested by GetAirQuality
+var AirQualityVariables = []string{
+	"european_aqi", "us_aqi", "pm2_5", "pm10", "ozone", "nitrogen_dioxide",
+	"alder_pollen", "birch_pollen", "grass_pollen", "mugwort_pollen", "olive_pollen", "ragweed_pollen",
+}
+
+// AirQualityProvider fetches the current air quality for a pair of coordinates
+type AirQualityProvider interface {
+	GetAirQuality(lat, lon float64) (*models.AirQuality, error)
+}
+
+var _ AirQualityProvider = (*Client)(nil)
+
+// WithAirQualityURL overrides the base URL of the air quality API
+func WithAirQualityURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.airQualityURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// GetAirQuality fetches the current pollutant concentrations, air quality
+// indexes and pollen counts. Pollen is only forecast for Europe during the
+// pollen season; elsewhere the pollen fields are nil.
+func (c *Client) GetAirQuality(lat, lon float64) (*models.AirQuality, error) {
+	return c.GetAirQualityContext(context.Background(), lat, lon)
+}
+
+// GetAirQualityContext is GetAirQuality with a context
+func (c *Client) GetAirQualityContext(ctx context.Context, lat, lon float64) (*models.AirQuality, error) {
+	ctx, cancel := c.policy.context(ctx)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/v1/air-quality?latitude=%.4f&longitude=%.4f&current=%s&timezone=auto",
+		c.airQualityURL, lat, lon, strings.Join(AirQualityVariables, ","))
+
+	body, err := c.fetch(ctx, "air quality", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var air models.AirQuality
+	if err := json.Unmarshal(body, &air); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &air, nil
+}
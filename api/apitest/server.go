@@ -0,0 +1,165 @@
+// Package apitest provides an in-process fake of the Open-Meteo APIs (and the
+// Nominatim reverse geocoding endpoint) backed by recorded fixtures, so the
+// api, cmd and ui packages can be exercised without network access.
+package apitest
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ugur-claw/uweather/api"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// Server is a fake Open-Meteo server serving the geocoding, forecast,
+// archive, air quality and marine endpoints from a single httptest.Server
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	requests  []string
+	overrides map[string]http.HandlerFunc
+}
+
+// NewServer starts a fake Open-Meteo server. Callers must Close it.
+func NewServer() *Server {
+	s := &Server{overrides: make(map[string]http.HandlerFunc)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Client returns an API client pointed at the fake server
+func (s *Server) Client(opts ...api.Option) *api.Client {
+	opts = append([]api.Option{
+		api.WithForecastURL(s.URL),
+		api.WithGeocodingURL(s.URL),
+		api.WithArchiveURL(s.URL),
+		api.WithAirQualityURL(s.URL),
+		api.WithMarineURL(s.URL),
+		api.WithHTTPClient(s.Server.Client()),
+	}, opts...)
+	return api.NewClient(opts...)
+}
+
+// ReverseGeocoder returns a Nominatim client pointed at the fake server
+func (s *Server) ReverseGeocoder() *api.Nominatim {
+	return api.NewNominatim(s.URL)
+}
+
+// Requests returns the path and query of every request received so far
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// Handle replaces the fixture handler for a path such as "/v1/forecast"
+func (s *Server) Handle(path string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides[path] = handler
+}
+
+// Fail makes every request to path answer with the given status and an
+// Open-Meteo style error body
+func (s *Server) Fail(path string, status int, reason string) {
+	s.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"error":true,"reason":%q}`, reason)
+	})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.URL.RequestURI())
+	override := s.overrides[r.URL.Path]
+	s.mu.Unlock()
+
+	if override != nil {
+		override(w, r)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/v1/search":
+		name := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("name")))
+		if !serveFixture(w, "search_"+strings.ReplaceAll(name, " ", "_")+".json") {
+			// Open-Meteo answers unknown names with an empty result set
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"generationtime_ms":0.5}`)
+		}
+	case "/v1/forecast":
+		serveForecast(w, r)
+	case "/v1/archive":
+		serveFixture(w, "archive.json")
+	case "/v1/air-quality":
+		serveFixture(w, "air_quality.json")
+	case "/v1/marine":
+		serveFixture(w, "marine.json")
+	case "/reverse":
+		serveFixture(w, "reverse.json")
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// serveForecast answers a forecast request from the fixture. Several comma
+// separated coordinates are answered with an array holding one copy of the
+// fixture per location, like the real API.
+func serveForecast(w http.ResponseWriter, r *http.Request) {
+	lats := strings.Split(r.URL.Query().Get("latitude"), ",")
+	lons := strings.Split(r.URL.Query().Get("longitude"), ",")
+	if len(lats) <= 1 {
+		serveFixture(w, "forecast.json")
+		return
+	}
+	if len(lats) != len(lons) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":true,"reason":"Parameter 'latitude' and 'longitude' must have the same number of elements"}`)
+		return
+	}
+
+	data, _ := fixtures.ReadFile("fixtures/forecast.json")
+	var forecast map[string]any
+	if err := json.Unmarshal(data, &forecast); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var forecasts []map[string]any
+	for i := range lats {
+		lat, _ := strconv.ParseFloat(lats[i], 64)
+		lon, _ := strconv.ParseFloat(lons[i], 64)
+		copied := maps.Clone(forecast)
+		copied["latitude"], copied["longitude"] = lat, lon
+		forecasts = append(forecasts, copied)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(forecasts)
+}
+
+// serveFixture writes a fixture file and reports whether it existed
+func serveFixture(w http.ResponseWriter, name string) bool {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		return false
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data)
+	return true
+}
+
+// Fixture returns the raw bytes of a recorded fixture
+func Fixture(name string) ([]byte, error) {
+	return fixtures.ReadFile("fixtures/" + name)
+}
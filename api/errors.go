@@ -0,0 +1,67 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Errors returned by the API clients, to be checked with errors.Is
+var (
+	// ErrCityNotFound means geocoding found no place with the given name
+	ErrCityNotFound = errors.New("city not found")
+	// ErrRateLimited means the API answered 429 Too Many Requests, even
+	// after the retries
+	ErrRateLimited = errors.New("rate limited")
+	// ErrNetwork means the API could not be reached or did not answer in time
+	ErrNetwork = errors.New("network error")
+	// ErrNoMarineData means the marine API has no sea state for a location,
+	// typically because it is inland
+	ErrNoMarineData = errors.New("no marine data")
+)
+
+// APIError is an error answer from an API, with the reason given in the
+// response body when there is one
+type APIError struct {
+	Service string // the API, e.g. "weather" or "geocoding"
+	Status  int
+	Reason  string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s API returned status %d", e.Service, e.Status)
+	if e.Status == http.StatusTooManyRequests {
+		msg += " (rate limited, try again later)"
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrRateLimited) match 429 answers
+func (e *APIError) Is(target error) bool {
+	return target == ErrRateLimited && e.Status == http.StatusTooManyRequests
+}
+
+// maxErrorBody bounds how much of an error answer is read for its reason
+const maxErrorBody = 64 << 10
+
+// newAPIError builds the APIError of a non-200 answer. Open-Meteo explains
+// errors as {"error": true, "reason": "..."}; other bodies leave Reason empty.
+func newAPIError(service string, resp *http.Response) *APIError {
+	apiErr := &APIError{Service: service, Status: resp.StatusCode}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if err != nil {
+		return apiErr
+	}
+	var errorBody struct {
+		Reason string `json:"reason"`
+	}
+	if json.Unmarshal(body, &errorBody) == nil {
+		apiErr.Reason = errorBody.Reason
+	}
+	return apiErr
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// DefaultArchiveURL is the base URL of the public Open-Meteo historical
+// weather API
+const DefaultArchiveURL = "https://archive-api.open-meteo.com"
+
+// FirstArchiveDate is the first day covered by the archive
+var FirstArchiveDate = time.Date(1940, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// HistoricalOptions selects what GetHistorical asks the archive API for
+type HistoricalOptions struct {
+	From, To time.Time // first and last day, inclusive
+	Daily    []string
+	Units    models.Units
+	// Timezone is the IANA zone that delimits the days; empty means the zone
+	// of the coordinates
+	Timezone string
+}
+
+// Normalize fills in the default variables, units and timezone
+func (o HistoricalOptions) Normalize() HistoricalOptions {
+	if len(o.Daily) == 0 {
+		o.Daily = DefaultDaily
+	}
+	if o.Units == (models.Units{}) {
+		o.Units = models.Metric
+	}
+	if o.Timezone == "" {
+		o.Timezone = "auto"
+	}
+	return o
+}
+
+// HistoricalProvider fetches past daily weather for a pair of coordinates
+type HistoricalProvider interface {
+	GetHistorical(lat, lon float64, opts HistoricalOptions) (*models.HistoricalWeather, error)
+}
+
+var _ HistoricalProvider = (*Client)(nil)
+
+// WithArchiveURL overrides the base URL of the historical weather API
+func WithArchiveURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.archiveURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// GetHistorical fetches the daily weather of a past date range from the
+// archive API
+func (c *Client) GetHistorical(lat, lon float64, opts HistoricalOptions) (*models.HistoricalWeather, error) {
+	return c.GetHistoricalContext(context.Background(), lat, lon, opts)
+}
+
+// GetHistoricalContext is GetHistorical with a context
+func (c *Client) GetHistoricalContext(ctx context.Context, lat, lon float64, opts HistoricalOptions) (*models.HistoricalWeather, error) {
+	ctx, cancel := c.policy.context(ctx)
+	defer cancel()
+
+	opts = opts.Normalize()
+	timezone := url.QueryEscape(opts.Timezone)
+
+	url := fmt.Sprintf("%s/v1/archive?latitude=%.4f&longitude=%.4f&start_date=%s&end_date=%s&daily=%s&timezone=%s&temperature_unit=%s&windspeed_unit=%s&precipitation_unit=%s",
+		c.archiveURL, lat, lon, opts.From.Format(time.DateOnly), opts.To.Format(time.DateOnly), strings.Join(opts.Daily, ","), timezone,
+		opts.Units.Temperature, opts.Units.Wind, opts.Units.Precipitation)
+
+	body, err := c.fetch(ctx, "historical weather", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var history models.HistoricalWeather
+	if err := json.Unmarshal(body, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return &history, nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// DefaultMarineURL is the base URL of the public Open-Meteo marine API
+const DefaultMarineURL = "https://marine-api.open-meteo.com"
+
+// MarineVariables are the current variables requested by GetMarine
+var MarineVariables = []string{
+	"wave_height", "wave_direction", "wave_period",
+	"swell_wave_height", "swell_wave_direction", "swell_wave_period",
+	"wind_wave_height", "sea_surface_temperature",
+}
+
+// MarineProvider fetches the current sea state for a pair of coordinates
+type MarineProvider interface {
+	GetMarine(lat, lon float64, units models.Units) (*models.Marine, error)
+}
+
+var _ MarineProvider = (*Client)(nil)
+
+// WithMarineURL overrides the base URL of the marine API
+func WithMarineURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.marineURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// GetMarine fetches the current waves and swell. Wave heights are in the
+// length unit of units, meters or feet. Coordinates without sea nearby return
+// ErrNoMarineData.
+func (c *Client) GetMarine(lat, lon float64, units models.Units) (*models.Marine, error) {
+	return c.GetMarineContext(context.Background(), lat, lon, units)
+}
+
+// GetMarineContext is GetMarine with a context
+func (c *Client) GetMarineContext(ctx context.Context, lat, lon float64, units models.Units) (*models.Marine, error) {
+	ctx, cancel := c.policy.context(ctx)
+	defer cancel()
+
+	lengthUnit := units.Length
+	if lengthUnit == "" {
+		lengthUnit = models.Metric.Length
+	}
+
+	url := fmt.Sprintf("%s/v1/marine?latitude=%.4f&longitude=%.4f&current=%s&length_unit=%s&timezone=auto",
+		c.marineURL, lat, lon, strings.Join(MarineVariables, ","), lengthUnit)
+
+	body, err := c.fetch(ctx, "marine", url)
+	if err != nil {
+		return nil, err
+	}
+
+	var marine models.Marine
+	if err := json.Unmarshal(body, &marine); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	// Land has no waves: the API answers with nulls rather than an error
+	if marine.Current.WaveHeight == nil {
+		return nil, fmt.Errorf("%w at %.4f, %.4f: the location is inland; use a coastal location or coordinates at sea", ErrNoMarineData, lat, lon)
+	}
+	return &marine, nil
+}
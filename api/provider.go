@@ -0,0 +1,48 @@
+package api
+
+import "github.com/ugur-claw/uweather/models"
+
+// WeatherProvider fetches forecast data for a pair of coordinates
+type WeatherProvider interface {
+	GetWeather(lat, lon float64, opts ForecastOptions) (*models.WeatherResponse, error)
+}
+
+// BatchWeatherProvider fetches the forecasts of many coordinates at once
+type BatchWeatherProvider interface {
+	GetWeatherBatch(coords []Coordinate, opts ForecastOptions) ([]*models.WeatherResponse, error)
+}
+
+// GetWeatherBatch fetches the forecasts of coords in order, in one go when
+// provider supports batches and one coordinate at a time otherwise
+func GetWeatherBatch(provider WeatherProvider, coords []Coordinate, opts ForecastOptions) ([]*models.WeatherResponse, error) {
+	if batch, ok := provider.(BatchWeatherProvider); ok {
+		return batch.GetWeatherBatch(coords, opts)
+	}
+
+	results := make([]*models.WeatherResponse, len(coords))
+	for i, coord := range coords {
+		weather, err := provider.GetWeather(coord.Lat, coord.Lon, opts)
+		if err != nil {
+			return nil, err
+		}
+		results[i] = weather
+	}
+	return results, nil
+}
+
+// Geocoder resolves a city name into candidate coordinates
+type Geocoder interface {
+	GeocodingMulti(query string) ([]models.GeocodingResult, error)
+}
+
+// Provider is a backend that can both geocode and fetch weather
+type Provider interface {
+	WeatherProvider
+	Geocoder
+}
+
+// Client is the default Provider backed by Open-Meteo
+var (
+	_ Provider             = (*Client)(nil)
+	_ BatchWeatherProvider = (*Client)(nil)
+)
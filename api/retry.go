@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Defaults of the request policy used by Client and Nominatim
+const (
+	// DefaultTimeout bounds a whole API call, retries included
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is the number of retries after the first attempt
+	DefaultRetries = 2
+
+	defaultBaseDelay = 500 * time.Millisecond
+	defaultMaxDelay  = 5 * time.Second
+)
+
+// requestPolicy bounds API calls in time and retries transient failures
+type requestPolicy struct {
+	timeout   time.Duration // whole call, retries included; 0 means none
+	retries   int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+func defaultRequestPolicy() requestPolicy {
+	return requestPolicy{
+		timeout:   DefaultTimeout,
+		retries:   DefaultRetries,
+		baseDelay: defaultBaseDelay,
+		maxDelay:  defaultMaxDelay,
+	}
+}
+
+// context derives the context of one API call from the caller's
+func (p requestPolicy) context(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p.timeout > 0 {
+		return context.WithTimeout(ctx, p.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// get performs a GET request. Network errors and 429 or 5xx answers are
+// retried with exponential backoff and jitter; a Retry-After header replaces
+// the computed delay, unless it is longer than the maximum delay, which ends
+// the retries. The last answer is returned as is, so the caller reports its
+// status. The caller closes the response body.
+func (p requestPolicy) get(ctx context.Context, httpClient *http.Client, rawURL string, header http.Header) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+
+		resp, err := httpClient.Do(req)
+		var delay time.Duration
+		switch {
+		case err != nil && ctx.Err() != nil:
+			return nil, p.contextErr(ctx)
+		case err != nil:
+			// Network error: retry below
+		case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+			delay = retryAfter(resp.Header.Get("Retry-After"))
+			if delay > p.maxDelay {
+				// Waiting that long is not worth it; a 429 is reported as
+				// ErrRateLimited
+				return resp, nil
+			}
+		default:
+			return resp, nil
+		}
+
+		if attempt >= p.retries {
+			if err != nil {
+				// The URL is long and says nothing the caller does not know
+				var urlErr *url.Error
+				if errors.As(err, &urlErr) {
+					err = urlErr.Err
+				}
+				return nil, fmt.Errorf("%w: %w", ErrNetwork, err)
+			}
+			return resp, nil
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		if delay == 0 {
+			delay = p.backoff(attempt)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, p.contextErr(ctx)
+		case <-timer.C:
+		}
+	}
+}
+
+// contextErr explains why ctx ended, naming the timeout when it expired. An
+// expired timeout is reported as ErrNetwork, a canceled call as is.
+func (p requestPolicy) contextErr(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && p.timeout > 0 {
+		return fmt.Errorf("%w: no answer within %s: %w", ErrNetwork, p.timeout, context.DeadlineExceeded)
+	}
+	return ctx.Err()
+}
+
+// backoff returns the delay before retry number attempt+1: the base delay
+// doubled per attempt, capped, with the upper half randomized so that many
+// clients do not retry in lockstep
+func (p requestPolicy) backoff(attempt int) time.Duration {
+	delay := p.maxDelay
+	if attempt < 30 {
+		delay = min(p.baseDelay<<attempt, p.maxDelay)
+	}
+	half := delay / 2
+	if half <= 0 {
+		return delay
+	}
+	return half + rand.N(half+1)
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP
+// date, returning 0 when it is absent or invalid
+func retryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
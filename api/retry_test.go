@@ -0,0 +1,161 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testPolicy retries quickly so that the tests do not wait on backoff
+func testPolicy() requestPolicy {
+	return requestPolicy{retries: 2, baseDelay: time.Millisecond, maxDelay: 2 * time.Second}
+}
+
+// statusServer answers request number n with statuses[n], repeating the last
+// one, and counts the requests
+func statusServer(t *testing.T, retryAfter string, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var requests atomic.Int32
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1)) - 1
+		status := statuses[min(n, len(statuses)-1)]
+		if status != http.StatusOK && retryAfter != "" {
+			w.Header().Set("Retry-After", retryAfter)
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s, &requests
+}
+
+func TestRequestPolicyRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		wantStatus   int
+		wantRequests int32
+	}{
+		{"success", []int{200}, "", 200, 1},
+		{"rate limited once", []int{429, 200}, "", 200, 2},
+		{"unavailable twice", []int{503, 503, 200}, "", 200, 3},
+		{"server error until the retries run out", []int{500}, "", 500, 3},
+		{"bad request", []int{400, 200}, "", 400, 1},
+		{"not found", []int{404, 200}, "", 404, 1},
+		{"retry after zero seconds", []int{429, 200}, "0", 200, 2},
+		{"retry after longer than the maximum delay", []int{429, 200}, "60", 429, 1},
+		{"retry after a date past the maximum delay", []int{503, 200}, time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), 503, 1},
+		{"invalid retry after", []int{503, 200}, "soon", 200, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, requests := statusServer(t, tt.retryAfter, tt.statuses...)
+
+			resp, err := testPolicy().get(context.Background(), s.Client(), s.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if got := requests.Load(); got != tt.wantRequests {
+				t.Errorf("got %d requests, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
+
+func TestRequestPolicyHonorsRetryAfter(t *testing.T) {
+	s, requests := statusServer(t, "1", http.StatusTooManyRequests, http.StatusOK)
+
+	start := time.Now()
+	resp, err := testPolicy().get(context.Background(), s.Client(), s.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want Retry-After's 1s", elapsed)
+	}
+	if resp.StatusCode != http.StatusOK || requests.Load() != 2 {
+		t.Errorf("status %d after %d requests", resp.StatusCode, requests.Load())
+	}
+}
+
+func TestRequestPolicyNetworkError(t *testing.T) {
+	s := httptest.NewServer(http.NotFoundHandler())
+	client, url := s.Client(), s.URL
+	s.Close()
+
+	_, err := testPolicy().get(context.Background(), client, url, nil)
+	if !errors.Is(err, ErrNetwork) {
+		t.Errorf("err = %v, want ErrNetwork", err)
+	}
+}
+
+func TestRequestPolicyTimeout(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer s.Close()
+
+	policy := testPolicy()
+	policy.timeout = 50 * time.Millisecond
+	ctx, cancel := policy.context(context.Background())
+	defer cancel()
+
+	_, err := policy.get(ctx, s.Client(), s.URL, nil)
+	if !errors.Is(err, ErrNetwork) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want ErrNetwork and DeadlineExceeded", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	p := requestPolicy{baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	for attempt, want := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		want *= time.Millisecond
+		for range 20 {
+			if got := p.backoff(attempt); got < want/2 || got > want {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, got, want/2, want)
+			}
+		}
+	}
+	if got := p.backoff(100); got < p.maxDelay/2 || got > p.maxDelay {
+		t.Errorf("backoff(100) = %s, want it capped at %s", got, p.maxDelay)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"", 0},
+		{"0", 0},
+		{"3", 3 * time.Second},
+		{strconv.Itoa(24 * 60 * 60), 24 * time.Hour},
+		{"-1", 0},
+		{"1.5", 0},
+		{"soon", 0},
+		{"Wed, 21 Oct 2015 07:28:00 GMT", 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.value); got != tt.want {
+			t.Errorf("retryAfter(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+
+	// A date in the future is the time left until then
+	at := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	if got := retryAfter(at); got <= 58*time.Second || got > time.Minute {
+		t.Errorf("retryAfter(%q) = %s, want about a minute", at, got)
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// DefaultReverseGeocodingURL is the base URL of the public Nominatim API.
+// Open-Meteo has no reverse geocoding endpoint.
+const DefaultReverseGeocodingURL = "https://nominatim.openstreetmap.org"
+
+// userAgent identifies uweather, as required by the Nominatim usage policy
+const userAgent = "uweather (https://github.com/ugur-claw/uweather)"
+
+// ReverseGeocoder names the place at a pair of coordinates
+type ReverseGeocoder interface {
+	ReverseGeocode(lat, lon float64) (*models.GeocodingResult, error)
+}
+
+// Nominatim is a ReverseGeocoder backed by an OpenStreetMap Nominatim server
+type Nominatim struct {
+	httpClient *http.Client
+	baseURL    string
+	language   string
+	policy     requestPolicy
+}
+
+// NewNominatim creates a Nominatim client. An empty baseURL uses the public
+// server.
+func NewNominatim(baseURL string) *Nominatim {
+	if baseURL == "" {
+		baseURL = DefaultReverseGeocodingURL
+	}
+	return &Nominatim{
+		httpClient: &http.Client{},
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		language:   DefaultLanguage,
+		policy:     defaultRequestPolicy(),
+	}
+}
+
+// SetTimeout bounds every reverse geocoding call, retries included. Zero
+// disables the limit.
+func (n *Nominatim) SetTimeout(timeout time.Duration) {
+	n.policy.timeout = max(timeout, 0)
+}
+
+// SetLanguage selects the language of the returned place names
+func (n *Nominatim) SetLanguage(language string) {
+	if language != "" {
+		n.language = language
+	}
+}
+
+// nominatimResponse is the subset of the jsonv2 reverse response we use
+type nominatimResponse struct {
+	Error   string `json:"error"`
+	Name    string `json:"name"`
+	Address struct {
+		City        string `json:"city"`
+		Town        string `json:"town"`
+		Village     string `json:"village"`
+		Hamlet      string `json:"hamlet"`
+		County      string `json:"county"`
+		State       string `json:"state"`
+		Country     string `json:"country"`
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+}
+
+// ReverseGeocode implements ReverseGeocoder
+func (n *Nominatim) ReverseGeocode(lat, lon float64) (*models.GeocodingResult, error) {
+	return n.ReverseGeocodeContext(context.Background(), lat, lon)
+}
+
+// ReverseGeocodeContext is ReverseGeocode with a context
+func (n *Nominatim) ReverseGeocodeContext(ctx context.Context, lat, lon float64) (*models.GeocodingResult, error) {
+	ctx, cancel := n.policy.context(ctx)
+	defer cancel()
+
+	url := fmt.Sprintf("%s/reverse?format=jsonv2&lat=%.5f&lon=%.5f&zoom=10&accept-language=%s", n.baseURL, lat, lon, n.language)
+
+	resp, err := n.policy.get(ctx, n.httpClient, url, http.Header{"User-Agent": {userAgent}})
+	if err != nil {
+		return nil, fmt.Errorf("reverse geocoding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError("reverse geocoding", resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var reverseResp nominatimResponse
+	if err := json.Unmarshal(body, &reverseResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if reverseResp.Error != "" {
+		return nil, fmt.Errorf("no place found at %.4f, %.4f: %s", lat, lon, reverseResp.Error)
+	}
+
+	addr := reverseResp.Address
+	name := firstNonEmpty(addr.City, addr.Town, addr.Village, addr.Hamlet, addr.County, reverseResp.Name, addr.State)
+	if name == "" {
+		return nil, fmt.Errorf("no place found at %.4f, %.4f", lat, lon)
+	}
+
+	return &models.GeocodingResult{
+		Name:        name,
+		Latitude:    lat,
+		Longitude:   lon,
+		Country:     addr.Country,
+		CountryCode: strings.ToUpper(addr.CountryCode),
+		Admin1:      addr.State,
+	}, nil
+}
+
+//go:embed places.csv
+var placesCSV string
+
+// OfflineGeocoder names coordinates after the nearest known place, without
+// any network access
+type OfflineGeocoder struct {
+	places []models.GeocodingResult
+}
+
+// NewOfflineGeocoder creates an OfflineGeocoder that knows the bundled list
+// of major cities plus any extra places, such as saved locations
+func NewOfflineGeocoder(extra ...models.GeocodingResult) *OfflineGeocoder {
+	places := append(bundledPlaces(), extra...)
+	return &OfflineGeocoder{places: places}
+}
+
+// ReverseGeocode implements ReverseGeocoder. Places further than a few
+// kilometres away are described relative to the nearest one, e.g.
+// "12 km NE of Izmir".
+func (o *OfflineGeocoder) ReverseGeocode(lat, lon float64) (*models.GeocodingResult, error) {
+	if len(o.places) == 0 {
+		return nil, fmt.Errorf("no known places")
+	}
+
+	nearest := o.places[0]
+	best := math.Inf(1)
+	for _, p := range o.places {
+		if d := DistanceKm(lat, lon, p.Latitude, p.Longitude); d < best {
+			best = d
+			nearest = p
+		}
+	}
+
+	name := nearest.Name
+	if best >= 5 {
+		bearing := Bearing(nearest.Latitude, nearest.Longitude, lat, lon)
+		name = fmt.Sprintf("%.0f km %s of %s", best, FormatWindDirection(bearing), nearest.Name)
+	}
+
+	return &models.GeocodingResult{
+		Name:        name,
+		Latitude:    lat,
+		Longitude:   lon,
+		Country:     nearest.Country,
+		CountryCode: nearest.CountryCode,
+		Admin1:      nearest.Admin1,
+	}, nil
+}
+
+// ReverseChain tries each ReverseGeocoder in turn and returns the first
+// answer, e.g. an online backend followed by the offline fallback
+type ReverseChain []ReverseGeocoder
+
+// ReverseGeocode implements ReverseGeocoder
+func (c ReverseChain) ReverseGeocode(lat, lon float64) (*models.GeocodingResult, error) {
+	err := fmt.Errorf("no reverse geocoder configured")
+	for _, g := range c {
+		var result *models.GeocodingResult
+		result, err = g.ReverseGeocode(lat, lon)
+		if err == nil {
+			return result, nil
+		}
+	}
+	return nil, err
+}
+
+// DistanceKm returns the great-circle distance between two points
+func DistanceKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dPhi := (lat2 - lat1) * math.Pi / 180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dPhi/2)*math.Sin(dPhi/2) + math.Cos(phi1)*math.Cos(phi2)*math.Sin(dLambda/2)*math.Sin(dLambda/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(a))
+}
+
+// Bearing returns the initial compass bearing in degrees from the first
+// point to the second
+func Bearing(lat1, lon1, lat2, lon2 float64) float64 {
+	phi1, phi2 := lat1*math.Pi/180, lat2*math.Pi/180
+	dLambda := (lon2 - lon1) * math.Pi / 180
+	y := math.Sin(dLambda) * math.Cos(phi2)
+	x := math.Cos(phi1)*math.Sin(phi2) - math.Sin(phi1)*math.Cos(phi2)*math.Cos(dLambda)
+	return math.Mod(math.Atan2(y, x)*180/math.Pi+360, 360)
+}
+
+// bundledPlaces parses the embedded list of major cities
+func bundledPlaces() []models.GeocodingResult {
+	records, err := csv.NewReader(strings.NewReader(placesCSV)).ReadAll()
+	if err != nil || len(records) < 2 {
+		return nil
+	}
+
+	places := make([]models.GeocodingResult, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		lat, latErr := strconv.ParseFloat(rec[3], 64)
+		lon, lonErr := strconv.ParseFloat(rec[4], 64)
+		if latErr != nil || lonErr != nil {
+			continue
+		}
+		places = append(places, models.GeocodingResult{
+			Name:        rec[0],
+			Country:     rec[1],
+			CountryCode: rec[2],
+			Latitude:    lat,
+			Longitude:   lon,
+		})
+	}
+	return places
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
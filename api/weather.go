@@ -1,26 +1,124 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
+	"time"
 
 	"github.com/ugur-claw/uweather/models"
 )
 
+const (
+	// DefaultForecastURL is the base URL of the public Open-Meteo forecast API
+	DefaultForecastURL = "https://api.open-meteo.com"
+	// DefaultGeocodingURL is the base URL of the public Open-Meteo geocoding API
+	DefaultGeocodingURL = "https://geocoding-api.open-meteo.com"
+	// DefaultLanguage is the language of place names returned by geocoding
+	DefaultLanguage = "en"
+)
+
 // Client handles Open-Meteo API requests
 type Client struct {
-	httpClient *http.Client
+	httpClient    *http.Client
+	forecastURL   string
+	geocodingURL  string
+	archiveURL    string
+	airQualityURL string
+	marineURL     string
+	language      string
+	policy        requestPolicy
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithForecastURL overrides the base URL of the forecast API
+func WithForecastURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.forecastURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithGeocodingURL overrides the base URL of the geocoding API
+func WithGeocodingURL(baseURL string) Option {
+	return func(c *Client) {
+		if baseURL != "" {
+			c.geocodingURL = strings.TrimRight(baseURL, "/")
+		}
+	}
+}
+
+// WithLanguage selects the language of place names returned by geocoding
+func WithLanguage(language string) Option {
+	return func(c *Client) {
+		if language != "" {
+			c.language = language
+		}
+	}
+}
+
+// WithTimeout bounds every API call, retries included. Zero disables the
+// limit.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.policy.timeout = max(timeout, 0)
+	}
+}
+
+// WithRetries sets how many times a failed request is retried. Network
+// errors, 429 and 5xx answers are retried; zero disables retries.
+func WithRetries(retries int) Option {
+	return func(c *Client) {
+		c.policy.retries = max(retries, 0)
+	}
+}
+
+// WithBackoff sets the delay before the first retry, doubled for every
+// further retry up to maxDelay. A server asking to wait longer than maxDelay
+// with Retry-After is not retried.
+func WithBackoff(baseDelay, maxDelay time.Duration) Option {
+	return func(c *Client) {
+		if baseDelay > 0 {
+			c.policy.baseDelay = baseDelay
+		}
+		if maxDelay > 0 {
+			c.policy.maxDelay = maxDelay
+		}
+	}
+}
+
+// WithHTTPClient replaces the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		if httpClient != nil {
+			c.httpClient = httpClient
+		}
+	}
 }
 
 // NewClient creates a new API client
-func NewClient() *Client {
-	return &Client{
-		httpClient: &http.Client{},
+func NewClient(opts ...Option) *Client {
+	c := &Client{
+		httpClient:    &http.Client{},
+		forecastURL:   DefaultForecastURL,
+		geocodingURL:  DefaultGeocodingURL,
+		archiveURL:    DefaultArchiveURL,
+		airQualityURL: DefaultAirQualityURL,
+		marineURL:     DefaultMarineURL,
+		language:      DefaultLanguage,
+		policy:        defaultRequestPolicy(),
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // Geocoding searches for a city and returns coordinates
@@ -30,7 +128,7 @@ func (c *Client) Geocoding(query string) (*models.GeocodingResult, error) {
 		return nil, err
 	}
 	if len(results) == 0 {
-		return nil, fmt.Errorf("city not found: %s", query)
+		return nil, fmt.Errorf("%w: %s", ErrCityNotFound, query)
 	}
 	// If only one result, return it directly
 	if len(results) == 1 {
@@ -43,19 +141,27 @@ func (c *Client) Geocoding(query string) (*models.GeocodingResult, error) {
 
 // GeocodingMulti searches for a city and returns all matching coordinates
 func (c *Client) GeocodingMulti(query string) ([]models.GeocodingResult, error) {
+	return c.GeocodingMultiContext(context.Background(), query)
+}
+
+// GeocodingMultiContext is GeocodingMulti with a context
+func (c *Client) GeocodingMultiContext(ctx context.Context, query string) ([]models.GeocodingResult, error) {
+	ctx, cancel := c.policy.context(ctx)
+	defer cancel()
+
 	// Encode the query
 	encodedQuery := url.QueryEscape(query)
 	// Request more results to allow selection
-	url := fmt.Sprintf("https://geocoding-api.open-meteo.com/v1/search?name=%s&count=10&language=en&format=json", encodedQuery)
+	url := fmt.Sprintf("%s/v1/search?name=%s&count=10&language=%s&format=json", c.geocodingURL, encodedQuery, c.language)
 
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.policy.get(ctx, c.httpClient, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("geocoding request failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("geocoding API returned status %d", resp.StatusCode)
+		return nil, newAPIError("geocoding", resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -69,7 +175,7 @@ func (c *Client) GeocodingMulti(query string) ([]models.GeocodingResult, error)
 	}
 
 	if len(geocodingResp.Results) == 0 {
-		return nil, fmt.Errorf("city not found: %s", query)
+		return nil, fmt.Errorf("%w: %s", ErrCityNotFound, query)
 	}
 
 	return geocodingResp.Results, nil
@@ -80,44 +186,171 @@ func (c *Client) GetGeocodingResults(query string) ([]models.GeocodingResult, er
 	return c.GeocodingMulti(query)
 }
 
+// Default variables requested from the forecast API
+var (
+	DefaultHourly = []string{"temperature_2m", "relativehumidity_2m"}
+	DefaultDaily  = []string{"temperature_2m_max", "temperature_2m_min", "weathercode", "precipitation_sum"}
+)
+
+// DefaultCurrent are the variables requested for the current conditions
+var DefaultCurrent = []string{
+	"temperature_2m", "apparent_temperature", "relative_humidity_2m", "dew_point_2m",
+	"surface_pressure", "cloud_cover", "wind_speed_10m", "wind_direction_10m",
+	"wind_gusts_10m", "uv_index", "visibility", "weather_code", "is_day",
+}
+
+// DetailedHourly are the hourly variables needed by the hour-by-hour view
+var DetailedHourly = []string{"temperature_2m", "relativehumidity_2m", "precipitation_probability", "weathercode"}
+
+// ForecastOptions selects what GetWeather asks the forecast API for
+type ForecastOptions struct {
+	Days     int // forecast days, today included
+	PastDays int // days before today to include
+	Current  []string
+	Hourly   []string
+	Daily    []string
+	Units    models.Units
+	// Timezone is the IANA zone used for the local times; empty means the
+	// zone of the coordinates
+	Timezone string
+}
+
+// Limits of the forecast API
+const (
+	MaxForecastDays = 16
+	MaxPastDays     = 92
+)
+
+// Normalize clamps the day counts and fills in the default variables and units
+func (o ForecastOptions) Normalize() ForecastOptions {
+	o.Days = min(max(o.Days, 1), MaxForecastDays)
+	o.PastDays = min(max(o.PastDays, 0), MaxPastDays)
+	if len(o.Current) == 0 {
+		o.Current = DefaultCurrent
+	}
+	if len(o.Hourly) == 0 {
+		o.Hourly = DefaultHourly
+	}
+	if len(o.Daily) == 0 {
+		o.Daily = DefaultDaily
+	}
+	if o.Units == (models.Units{}) {
+		o.Units = models.Metric
+	}
+	if o.Timezone == "" {
+		o.Timezone = "auto"
+	}
+	return o
+}
+
 // GetWeather fetches weather data for given coordinates
-func (c *Client) GetWeather(lat, lon float64, days int) (*models.WeatherResponse, error) {
-	// Limit days to max 7
-	if days > 7 {
-		days = 7
+func (c *Client) GetWeather(lat, lon float64, opts ForecastOptions) (*models.WeatherResponse, error) {
+	return c.GetWeatherContext(context.Background(), lat, lon, opts)
+}
+
+// GetWeatherContext is GetWeather with a context
+func (c *Client) GetWeatherContext(ctx context.Context, lat, lon float64, opts ForecastOptions) (*models.WeatherResponse, error) {
+	body, err := c.fetchForecast(ctx, fmt.Sprintf("%.4f", lat), fmt.Sprintf("%.4f", lon), opts.Normalize())
+	if err != nil {
+		return nil, err
 	}
-	if days < 1 {
-		days = 1
+
+	var weatherResp models.WeatherResponse
+	if err := json.Unmarshal(body, &weatherResp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	return &weatherResp, nil
+}
+
+// Coordinate is a point to fetch a forecast for
+type Coordinate struct {
+	Lat, Lon float64
+}
+
+// MaxBatchSize is the number of coordinates GetWeatherBatch packs into one
+// request, which keeps the URL well below common length limits
+const MaxBatchSize = 50
+
+// GetWeatherBatch fetches the forecasts of many coordinates with as few
+// requests as possible, using the comma separated coordinate lists of the
+// forecast API. The responses are in the same order as coords.
+func (c *Client) GetWeatherBatch(coords []Coordinate, opts ForecastOptions) ([]*models.WeatherResponse, error) {
+	return c.GetWeatherBatchContext(context.Background(), coords, opts)
+}
+
+// GetWeatherBatchContext is GetWeatherBatch with a context
+func (c *Client) GetWeatherBatchContext(ctx context.Context, coords []Coordinate, opts ForecastOptions) ([]*models.WeatherResponse, error) {
+	opts = opts.Normalize()
+
+	results := make([]*models.WeatherResponse, 0, len(coords))
+	for start := 0; start < len(coords); start += MaxBatchSize {
+		chunk := coords[start:min(start+MaxBatchSize, len(coords))]
+		lats := make([]string, len(chunk))
+		lons := make([]string, len(chunk))
+		for i, coord := range chunk {
+			lats[i] = fmt.Sprintf("%.4f", coord.Lat)
+			lons[i] = fmt.Sprintf("%.4f", coord.Lon)
+		}
+
+		body, err := c.fetchForecast(ctx, strings.Join(lats, ","), strings.Join(lons, ","), opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// A single location is answered with an object, several with an array
+		var responses []*models.WeatherResponse
+		if len(chunk) == 1 {
+			var weatherResp models.WeatherResponse
+			err = json.Unmarshal(body, &weatherResp)
+			responses = append(responses, &weatherResp)
+		} else {
+			err = json.Unmarshal(body, &responses)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse response: %w", err)
+		}
+		if len(responses) != len(chunk) {
+			return nil, fmt.Errorf("weather API returned %d forecasts for %d locations", len(responses), len(chunk))
+		}
+		results = append(results, responses...)
 	}
+	return results, nil
+}
+
+// fetchForecast requests the forecast for one or more comma separated
+// coordinates and returns the raw response body
+func (c *Client) fetchForecast(ctx context.Context, latitudes, longitudes string, opts ForecastOptions) ([]byte, error) {
+	ctx, cancel := c.policy.context(ctx)
+	defer cancel()
 
-	// Build hourly params for today
-	hourlyParams := "temperature_2m,relativehumidity_2m"
-	dailyParams := "temperature_2m_max,temperature_2m_min,weathercode,precipitation_sum"
+	timezone := url.QueryEscape(opts.Timezone)
 
-	url := fmt.Sprintf("https://api.open-meteo.com/v1/forecast?latitude=%.4f&longitude=%.4f&current_weather=true&hourly=%s&daily=%s&timezone=auto&forecast_days=%d",
-		lat, lon, hourlyParams, dailyParams, days)
+	url := fmt.Sprintf("%s/v1/forecast?latitude=%s&longitude=%s&current=%s&hourly=%s&daily=%s&timezone=%s&forecast_days=%d&past_days=%d&temperature_unit=%s&windspeed_unit=%s&precipitation_unit=%s",
+		c.forecastURL, latitudes, longitudes, strings.Join(opts.Current, ","), strings.Join(opts.Hourly, ","), strings.Join(opts.Daily, ","), timezone, opts.Days, opts.PastDays,
+		opts.Units.Temperature, opts.Units.Wind, opts.Units.Precipitation)
 
-	resp, err := c.httpClient.Get(url)
+	return c.fetch(ctx, "weather", url)
+}
+
+// fetch performs a GET request to one of the Open-Meteo APIs and returns the
+// body of a successful answer. service names the API in errors.
+func (c *Client) fetch(ctx context.Context, service, url string) ([]byte, error) {
+	resp, err := c.policy.get(ctx, c.httpClient, url, nil)
 	if err != nil {
-		return nil, fmt.Errorf("weather request failed: %w", err)
+		return nil, fmt.Errorf("%s request failed: %w", service, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("weather API returned status %d", resp.StatusCode)
+		return nil, newAPIError(service, resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
-
-	var weatherResp models.WeatherResponse
-	if err := json.Unmarshal(body, &weatherResp); err != nil {
-		return nil, fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	return &weatherResp, nil
+	return body, nil
 }
 
 // GetWeatherCodeDescription returns description for WMO weather code
@@ -253,7 +486,7 @@ func GetWeatherArt(code int) string {
 // FormatWindDirection converts wind direction degrees to cardinal direction
 func FormatWindDirection(degrees float64) string {
 	dirs := []string{"N", "NE", "E", "SE", "S", "SW", "W", "NW"}
-	index := int((degrees + 22.5) / 45) % 8
+	index := int((degrees+22.5)/45) % 8
 	return dirs[index]
 }
 
@@ -0,0 +1,266 @@
+package api_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/api/apitest"
+	"github.com/ugur-claw/uweather/models"
+)
+
+func TestGetWeather(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+
+	weather, err := s.Client().GetWeather(41.01, 28.95, api.ForecastOptions{Days: 3, Units: models.Imperial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if weather.CurrentWeather.Temperature != 18.4 {
+		t.Errorf("temperature = %v, want 18.4", weather.CurrentWeather.Temperature)
+	}
+	if weather.CurrentUnits["visibility"] != "m" {
+		t.Errorf("visibility unit = %q, want m", weather.CurrentUnits["visibility"])
+	}
+	if len(weather.Daily.Time) == 0 || len(weather.Daily.Time) != len(weather.Daily.TemperatureMax) {
+		t.Errorf("daily has %d days and %d maxima", len(weather.Daily.Time), len(weather.Daily.TemperatureMax))
+	}
+
+	requests := s.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	for _, param := range []string{"latitude=41.0100", "longitude=28.9500", "forecast_days=3", "temperature_unit=fahrenheit", "windspeed_unit=mph", "precipitation_unit=inch", "timezone=auto"} {
+		if !strings.Contains(requests[0], param) {
+			t.Errorf("request %s lacks %s", requests[0], param)
+		}
+	}
+}
+
+func TestGetWeatherBatch(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+
+	coords := []api.Coordinate{{Lat: 41.01, Lon: 28.95}, {Lat: 48.85, Lon: 2.35}, {Lat: 51.51, Lon: -0.13}}
+	weathers, err := s.Client().GetWeatherBatch(coords, api.ForecastOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(weathers) != len(coords) {
+		t.Fatalf("got %d forecasts, want %d", len(weathers), len(coords))
+	}
+	for i, weather := range weathers {
+		if weather == nil || weather.CurrentWeather.Temperature != 18.4 {
+			t.Errorf("forecast %d = %+v", i, weather)
+		}
+	}
+
+	requests := s.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("got %d requests, want 1", len(requests))
+	}
+	if !strings.Contains(requests[0], "latitude=41.0100,48.8500,51.5100") {
+		t.Errorf("request %s does not list the latitudes", requests[0])
+	}
+}
+
+// echoLatitudes answers forecast requests with one forecast per location whose
+// timezone names its latitude, as an object for one location like the real API
+func echoLatitudes(w http.ResponseWriter, r *http.Request) {
+	var forecasts []models.WeatherResponse
+	for _, lat := range strings.Split(r.URL.Query().Get("latitude"), ",") {
+		forecasts = append(forecasts, models.WeatherResponse{Timezone: "lat=" + lat})
+	}
+	if len(forecasts) == 1 {
+		json.NewEncoder(w).Encode(forecasts[0])
+		return
+	}
+	json.NewEncoder(w).Encode(forecasts)
+}
+
+func TestGetWeatherBatchChunks(t *testing.T) {
+	tests := []struct {
+		locations int
+		wantSizes []int
+	}{
+		{1, []int{1}},
+		{api.MaxBatchSize, []int{api.MaxBatchSize}},
+		{api.MaxBatchSize + 1, []int{api.MaxBatchSize, 1}},
+		{120, []int{50, 50, 20}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.locations), func(t *testing.T) {
+			s := apitest.NewServer()
+			defer s.Close()
+			s.Handle("/v1/forecast", echoLatitudes)
+
+			coords := make([]api.Coordinate, tt.locations)
+			for i := range coords {
+				coords[i] = api.Coordinate{Lat: -60 + float64(i), Lon: 28.95}
+			}
+			weathers, err := s.Client().GetWeatherBatch(coords, api.ForecastOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var sizes []int
+			for _, request := range s.Requests() {
+				u, err := url.Parse(request)
+				if err != nil {
+					t.Fatal(err)
+				}
+				sizes = append(sizes, len(strings.Split(u.Query().Get("latitude"), ",")))
+			}
+			if !slices.Equal(sizes, tt.wantSizes) {
+				t.Errorf("request sizes %v, want %v", sizes, tt.wantSizes)
+			}
+
+			if len(weathers) != len(coords) {
+				t.Fatalf("got %d forecasts, want %d", len(weathers), len(coords))
+			}
+			for i, weather := range weathers {
+				if want := fmt.Sprintf("lat=%.4f", coords[i].Lat); weather.Timezone != want {
+					t.Errorf("forecast %d is for %s, want %s", i, weather.Timezone, want)
+				}
+			}
+		})
+	}
+}
+
+func TestGetWeatherBatchBadResponse(t *testing.T) {
+	tests := []struct {
+		name      string
+		locations int
+		body      string
+		wantErr   string
+	}{
+		{
+			name:      "fewer forecasts than locations",
+			locations: 3,
+			body:      `[{"timezone":"GMT"},{"timezone":"GMT"}]`,
+			wantErr:   "weather API returned 2 forecasts for 3 locations",
+		},
+		{
+			name:      "more forecasts than locations",
+			locations: 2,
+			body:      `[{"timezone":"GMT"},{"timezone":"GMT"},{"timezone":"GMT"}]`,
+			wantErr:   "weather API returned 3 forecasts for 2 locations",
+		},
+		{
+			name:      "object for several locations",
+			locations: 2,
+			body:      `{"timezone":"GMT"}`,
+			wantErr:   "failed to parse response",
+		},
+		{
+			name:      "array for one location",
+			locations: 1,
+			body:      `[{"timezone":"GMT"}]`,
+			wantErr:   "failed to parse response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.NewServer()
+			defer s.Close()
+			s.Handle("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.body))
+			})
+
+			coords := make([]api.Coordinate, tt.locations)
+			_, err := s.Client().GetWeatherBatch(coords, api.ForecastOptions{})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("err = %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGeocodingNotFound(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+
+	_, err := s.Client().GeocodingMulti("Atlantis")
+	if !errors.Is(err, api.ErrCityNotFound) {
+		t.Errorf("err = %v, want ErrCityNotFound", err)
+	}
+}
+
+func TestAPIError(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		body        string
+		wantReason  string
+		rateLimited bool
+	}{
+		{
+			name:       "open-meteo reason",
+			status:     http.StatusBadRequest,
+			body:       `{"error":true,"reason":"Latitude must be in range of -90 to 90°. Given: 100.0."}`,
+			wantReason: "Latitude must be in range of -90 to 90°. Given: 100.0.",
+		},
+		{
+			name:        "rate limited",
+			status:      http.StatusTooManyRequests,
+			body:        `{"error":true,"reason":"Minutely API request limit exceeded"}`,
+			wantReason:  "Minutely API request limit exceeded",
+			rateLimited: true,
+		},
+		{
+			name:   "body that is not json",
+			status: http.StatusBadGateway,
+			body:   "<html>Bad Gateway</html>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := apitest.NewServer()
+			defer s.Close()
+			s.Handle("/v1/forecast", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			_, err := s.Client(api.WithRetries(0)).GetWeather(41, 29, api.ForecastOptions{})
+			var apiErr *api.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an APIError", err)
+			}
+			if apiErr.Service != "weather" || apiErr.Status != tt.status || apiErr.Reason != tt.wantReason {
+				t.Errorf("got %+v, want status %d and reason %q", apiErr, tt.status, tt.wantReason)
+			}
+			if errors.Is(err, api.ErrRateLimited) != tt.rateLimited {
+				t.Errorf("errors.Is(err, ErrRateLimited) = %v, want %v", !tt.rateLimited, tt.rateLimited)
+			}
+		})
+	}
+}
+
+func TestAPIErrorFromFail(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	s.Fail("/v1/search", http.StatusBadRequest, "Parameter count must be between 1 and 100")
+
+	_, err := s.Client().GeocodingMulti("Paris")
+	var apiErr *api.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v, want an APIError", err)
+	}
+	if apiErr.Service != "geocoding" || apiErr.Reason != "Parameter count must be between 1 and 100" {
+		t.Errorf("got %+v", apiErr)
+	}
+	if !strings.HasSuffix(err.Error(), ": Parameter count must be between 1 and 100") {
+		t.Errorf("message %q does not end with the reason", err)
+	}
+}
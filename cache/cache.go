@@ -0,0 +1,339 @@
+// Package cache wraps an api.Provider with an on-disk response cache.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+const (
+	// DefaultTTL is how long a forecast stays fresh when no TTL is configured
+	DefaultTTL = 10 * time.Minute
+	// GeocodingTTL is how long geocoding results stay fresh. Cities rarely move.
+	GeocodingTTL = 30 * 24 * time.Hour
+	// HistoricalTTL is how long historical weather stays fresh. The archive
+	// only revises its last few days, as better data comes in.
+	HistoricalTTL = 24 * time.Hour
+)
+
+// entry is the on-disk representation of a cached response
+type entry struct {
+	Key       string          `json:"key"`
+	FetchedAt time.Time       `json:"fetched_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Provider serves forecasts and geocoding results from disk when they are
+// fresh enough and falls back to the wrapped provider otherwise.
+//
+// Entries older than the TTL but younger than twice the TTL are served stale
+// while a background goroutine refreshes them; call Wait before exiting so the
+// refresh can finish.
+type Provider struct {
+	next     api.Provider
+	dir      string
+	ttl      time.Duration
+	refresh  bool
+	language string
+
+	wg sync.WaitGroup
+}
+
+// New wraps next with a cache stored in dir
+func New(next api.Provider, dir string, ttl time.Duration) *Provider {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Provider{next: next, dir: dir, ttl: ttl}
+}
+
+// SetRefresh makes the provider skip cached entries and always refetch,
+// while still storing the new responses
+func (p *Provider) SetRefresh(refresh bool) {
+	p.refresh = refresh
+}
+
+// SetLanguage records the geocoding language so that results in different
+// languages are cached separately
+func (p *Provider) SetLanguage(language string) {
+	p.language = language
+}
+
+// Wait blocks until background refreshes have completed
+func (p *Provider) Wait() {
+	p.wg.Wait()
+}
+
+// GetWeather implements api.WeatherProvider
+func (p *Provider) GetWeather(lat, lon float64, opts api.ForecastOptions) (*models.WeatherResponse, error) {
+	opts = opts.Normalize()
+	key := ForecastKey(lat, lon, opts)
+
+	var weather models.WeatherResponse
+	err := p.get(key, p.ttl, &weather, func() (any, error) {
+		return p.next.GetWeather(lat, lon, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &weather, nil
+}
+
+// GetWeatherBatch implements api.BatchWeatherProvider. Fresh entries are
+// served from the cache and the rest are fetched in one batch; stale entries
+// are served while the batch refreshing them runs in the background.
+func (p *Provider) GetWeatherBatch(coords []api.Coordinate, opts api.ForecastOptions) ([]*models.WeatherResponse, error) {
+	opts = opts.Normalize()
+
+	results := make([]*models.WeatherResponse, len(coords))
+	cached := make([]*models.WeatherResponse, len(coords))
+	var missing, stale []int
+	for i, coord := range coords {
+		e, err := p.load(ForecastKey(coord.Lat, coord.Lon, opts))
+		var weather models.WeatherResponse
+		if err != nil || json.Unmarshal(e.Data, &weather) != nil {
+			missing = append(missing, i)
+			continue
+		}
+		cached[i] = &weather
+
+		age := time.Since(e.FetchedAt)
+		switch {
+		case p.refresh || age >= 2*p.ttl:
+			missing = append(missing, i)
+		case age >= p.ttl:
+			results[i] = &weather
+			stale = append(stale, i)
+		default:
+			results[i] = &weather
+		}
+	}
+
+	if len(stale) > 0 {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			p.fetchBatch(coords, stale, opts)
+		}()
+	}
+
+	if len(missing) > 0 {
+		fetched, err := p.fetchBatch(coords, missing, opts)
+		if err != nil {
+			// An old answer beats no answer when the API is unreachable
+			for _, i := range missing {
+				if cached[i] == nil {
+					return nil, err
+				}
+				results[i] = cached[i]
+			}
+			return results, nil
+		}
+		for j, i := range missing {
+			results[i] = fetched[j]
+		}
+	}
+	return results, nil
+}
+
+// fetchBatch fetches the coordinates at the given indexes and stores them
+func (p *Provider) fetchBatch(coords []api.Coordinate, indexes []int, opts api.ForecastOptions) ([]*models.WeatherResponse, error) {
+	subset := make([]api.Coordinate, len(indexes))
+	for j, i := range indexes {
+		subset[j] = coords[i]
+	}
+
+	fetched, err := api.GetWeatherBatch(p.next, subset, opts)
+	if err != nil {
+		return nil, err
+	}
+	for j, coord := range subset {
+		p.store(ForecastKey(coord.Lat, coord.Lon, opts), fetched[j])
+	}
+	return fetched, nil
+}
+
+// GeocodingMulti implements api.Geocoder
+func (p *Provider) GeocodingMulti(query string) ([]models.GeocodingResult, error) {
+	key := "geocoding|" + strings.ToLower(strings.TrimSpace(query))
+	if p.language != "" && p.language != api.DefaultLanguage {
+		key += "|" + p.language
+	}
+
+	var results []models.GeocodingResult
+	err := p.get(key, GeocodingTTL, &results, func() (any, error) {
+		return p.next.GeocodingMulti(query)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetHistorical implements api.HistoricalProvider when the wrapped provider
+// does
+func (p *Provider) GetHistorical(lat, lon float64, opts api.HistoricalOptions) (*models.HistoricalWeather, error) {
+	next, ok := p.next.(api.HistoricalProvider)
+	if !ok {
+		return nil, fmt.Errorf("historical weather is not supported by this provider")
+	}
+
+	opts = opts.Normalize()
+	key := fmt.Sprintf("history|%.2f,%.2f|from=%s|to=%s|daily=%s|units=%s|tz=%s",
+		lat, lon, opts.From.Format(time.DateOnly), opts.To.Format(time.DateOnly), strings.Join(opts.Daily, ","), opts.Units, opts.Timezone)
+
+	var history models.HistoricalWeather
+	err := p.get(key, HistoricalTTL, &history, func() (any, error) {
+		return next.GetHistorical(lat, lon, opts)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &history, nil
+}
+
+// GetAirQuality implements api.AirQualityProvider when the wrapped provider
+// does. Air quality stays fresh as long as forecasts.
+func (p *Provider) GetAirQuality(lat, lon float64) (*models.AirQuality, error) {
+	next, ok := p.next.(api.AirQualityProvider)
+	if !ok {
+		return nil, fmt.Errorf("air quality is not supported by this provider")
+	}
+
+	key := fmt.Sprintf("air|%.2f,%.2f|current=%s", lat, lon, strings.Join(api.AirQualityVariables, ","))
+
+	var air models.AirQuality
+	err := p.get(key, p.ttl, &air, func() (any, error) {
+		return next.GetAirQuality(lat, lon)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &air, nil
+}
+
+// GetMarine implements api.MarineProvider when the wrapped provider does.
+// The sea state stays fresh as long as forecasts.
+func (p *Provider) GetMarine(lat, lon float64, units models.Units) (*models.Marine, error) {
+	next, ok := p.next.(api.MarineProvider)
+	if !ok {
+		return nil, fmt.Errorf("marine forecasts are not supported by this provider")
+	}
+
+	key := fmt.Sprintf("marine|%.2f,%.2f|current=%s|units=%s", lat, lon, strings.Join(api.MarineVariables, ","), units)
+
+	var marine models.Marine
+	err := p.get(key, p.ttl, &marine, func() (any, error) {
+		return next.GetMarine(lat, lon, units)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &marine, nil
+}
+
+// ForecastKey builds the cache key for a forecast request. Coordinates are
+// rounded to two decimals (about 1 km) so nearby lookups share an entry.
+func ForecastKey(lat, lon float64, opts api.ForecastOptions) string {
+	return fmt.Sprintf("forecast|%.2f,%.2f|days=%d|past=%d|current=%s|hourly=%s|daily=%s|units=%s|tz=%s",
+		lat, lon, opts.Days, opts.PastDays, strings.Join(opts.Current, ","), strings.Join(opts.Hourly, ","), strings.Join(opts.Daily, ","), opts.Units, opts.Timezone)
+}
+
+// get decodes a cached value for key into out, calling fetch when the entry
+// is missing or expired
+func (p *Provider) get(key string, ttl time.Duration, out any, fetch func() (any, error)) error {
+	cached, err := p.load(key)
+	if err == nil && !p.refresh {
+		age := time.Since(cached.FetchedAt)
+		if age < 2*ttl && json.Unmarshal(cached.Data, out) == nil {
+			if age >= ttl {
+				p.wg.Add(1)
+				go func() {
+					defer p.wg.Done()
+					if value, err := fetch(); err == nil {
+						p.store(key, value)
+					}
+				}()
+			}
+			return nil
+		}
+	}
+
+	value, fetchErr := fetch()
+	if fetchErr != nil {
+		// An old answer beats no answer when the API is unreachable
+		if cached != nil && json.Unmarshal(cached.Data, out) == nil {
+			return nil
+		}
+		return fetchErr
+	}
+	p.store(key, value)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode response: %w", err)
+	}
+	return json.Unmarshal(data, out)
+}
+
+func (p *Provider) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(p.dir, hex.EncodeToString(sum[:16])+".json")
+}
+
+func (p *Provider) load(key string) (*entry, error) {
+	data, err := os.ReadFile(p.path(key))
+	if err != nil {
+		return nil, err
+	}
+	var e entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil, err
+	}
+	if e.Key != key {
+		return nil, fmt.Errorf("cache key mismatch")
+	}
+	return &e, nil
+}
+
+// store writes an entry through a temp file so concurrent readers never see
+// a partial file. Failures are ignored; the cache is best effort.
+func (p *Provider) store(key string, value any) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return
+	}
+	raw, err := json.Marshal(entry{Key: key, FetchedAt: time.Now(), Data: data})
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(p.dir, ".tmp-*")
+	if err != nil {
+		return
+	}
+	if _, err := tmp.Write(raw); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return
+	}
+	if err := os.Rename(tmp.Name(), p.path(key)); err != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+const testTTL = 10 * time.Minute
+
+// fakeProvider answers every forecast with the number of the call in the
+// timezone field, or with err when it is set
+type fakeProvider struct {
+	mu    sync.Mutex
+	calls int
+	err   error
+}
+
+func (f *fakeProvider) GetWeather(lat, lon float64, opts api.ForecastOptions) (*models.WeatherResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &models.WeatherResponse{Timezone: fmt.Sprintf("call %d", f.calls)}, nil
+}
+
+func (f *fakeProvider) GeocodingMulti(query string) ([]models.GeocodingResult, error) {
+	return nil, api.ErrCityNotFound
+}
+
+// seed stores a forecast for coord fetched age ago; a negative age stores
+// nothing
+func seed(t *testing.T, p *Provider, coord api.Coordinate, age time.Duration) {
+	t.Helper()
+	if age < 0 {
+		return
+	}
+	key := ForecastKey(coord.Lat, coord.Lon, api.ForecastOptions{}.Normalize())
+	data, err := json.Marshal(models.WeatherResponse{Timezone: "cached"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(entry{Key: key, FetchedAt: time.Now().Add(-age), Data: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(p.dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p.path(key), raw, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// stored returns the timezone of the forecast cached for coord
+func stored(t *testing.T, p *Provider, coord api.Coordinate) string {
+	t.Helper()
+	e, err := p.load(ForecastKey(coord.Lat, coord.Lon, api.ForecastOptions{}.Normalize()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var weather models.WeatherResponse
+	if err := json.Unmarshal(e.Data, &weather); err != nil {
+		t.Fatal(err)
+	}
+	return weather.Timezone
+}
+
+func TestGetWeatherFreshness(t *testing.T) {
+	tests := []struct {
+		name       string
+		age        time.Duration // of the cached entry; negative for none
+		refresh    bool
+		fetchErr   error
+		want       string
+		wantCalls  int
+		wantStored string
+	}{
+		{name: "not cached", age: -1, want: "call 1", wantCalls: 1, wantStored: "call 1"},
+		{name: "fresh", age: 0, want: "cached", wantStored: "cached"},
+		{name: "just under the ttl", age: testTTL - time.Minute, want: "cached", wantStored: "cached"},
+		{name: "at the ttl", age: testTTL, want: "cached", wantCalls: 1, wantStored: "call 1"},
+		{name: "stale", age: testTTL + time.Minute, want: "cached", wantCalls: 1, wantStored: "call 1"},
+		{name: "at twice the ttl", age: 2 * testTTL, want: "call 1", wantCalls: 1, wantStored: "call 1"},
+		{name: "expired", age: 3 * testTTL, want: "call 1", wantCalls: 1, wantStored: "call 1"},
+		{name: "refresh", age: 0, refresh: true, want: "call 1", wantCalls: 1, wantStored: "call 1"},
+		{name: "stale refresh fails", age: testTTL + time.Minute, fetchErr: api.ErrNetwork, want: "cached", wantCalls: 1, wantStored: "cached"},
+		{name: "expired and unreachable", age: 3 * testTTL, fetchErr: api.ErrNetwork, want: "cached", wantCalls: 1, wantStored: "cached"},
+	}
+
+	coord := api.Coordinate{Lat: 41.01, Lon: 28.95}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &fakeProvider{err: tt.fetchErr}
+			p := New(next, t.TempDir(), testTTL)
+			p.SetRefresh(tt.refresh)
+			seed(t, p, coord, tt.age)
+
+			weather, err := p.GetWeather(coord.Lat, coord.Lon, api.ForecastOptions{})
+			p.Wait()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if weather.Timezone != tt.want {
+				t.Errorf("served %q, want %q", weather.Timezone, tt.want)
+			}
+			if next.calls != tt.wantCalls {
+				t.Errorf("%d fetches, want %d", next.calls, tt.wantCalls)
+			}
+			if got := stored(t, p, coord); got != tt.wantStored {
+				t.Errorf("cache holds %q, want %q", got, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestGetWeatherNotCachedAndUnreachable(t *testing.T) {
+	p := New(&fakeProvider{err: api.ErrNetwork}, t.TempDir(), testTTL)
+	if _, err := p.GetWeather(41.01, 28.95, api.ForecastOptions{}); !errors.Is(err, api.ErrNetwork) {
+		t.Errorf("err = %v, want ErrNetwork", err)
+	}
+}
+
+func TestGetWeatherBatchFreshness(t *testing.T) {
+	next := &fakeProvider{}
+	p := New(next, t.TempDir(), testTTL)
+	coords := []api.Coordinate{{Lat: 1}, {Lat: 2}, {Lat: 3}, {Lat: 4}}
+	seed(t, p, coords[0], 0)                     // fresh
+	seed(t, p, coords[1], testTTL+time.Minute)   // stale
+	seed(t, p, coords[2], 2*testTTL+time.Minute) // expired
+	seed(t, p, coords[3], -1)                    // missing
+
+	weathers, err := p.GetWeatherBatch(coords, api.ForecastOptions{})
+	p.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The expired and missing entries are fetched right away, in order; the
+	// stale one is served and refreshed in the background
+	served := make([]string, len(weathers))
+	for i, weather := range weathers {
+		served[i] = weather.Timezone
+	}
+	if served[0] != "cached" || served[1] != "cached" || served[2] == "cached" || served[3] == "cached" {
+		t.Errorf("served %q", served)
+	}
+	if next.calls != 3 {
+		t.Errorf("%d fetches, want 3", next.calls)
+	}
+	for i, coord := range coords[1:] {
+		if got := stored(t, p, coord); got == "cached" {
+			t.Errorf("entry %d was not refreshed", i+1)
+		}
+	}
+}
+
+func TestForecastKey(t *testing.T) {
+	opts := api.ForecastOptions{}.Normalize()
+	if ForecastKey(41.0101, 28.9549, opts) != ForecastKey(41.0149, 28.9501, opts) {
+		t.Error("nearby coordinates do not share an entry")
+	}
+	if ForecastKey(41.01, 28.95, opts) == ForecastKey(41.02, 28.95, opts) {
+		t.Error("coordinates about 1 km apart share an entry")
+	}
+	other := opts
+	other.Days = 3
+	if ForecastKey(41.01, 28.95, opts) == ForecastKey(41.01, 28.95, other) {
+		t.Error("different days share an entry")
+	}
+	other = opts
+	other.Units = models.Imperial
+	if ForecastKey(41.01, 28.95, opts) == ForecastKey(41.01, 28.95, other) {
+		t.Error("different units share an entry")
+	}
+}
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// AirCommand shows the air quality and pollen counts of a saved label, a city
+// name or, when target is empty, the default location
+func AirCommand(provider api.Provider, target string, sel CityOptions, output OutputFormat) error {
+	aq, ok := provider.(api.AirQualityProvider)
+	if !ok {
+		return fmt.Errorf("air quality is not supported by this provider")
+	}
+
+	location, err := lookupLocation(provider, target, sel)
+	if err != nil {
+		return err
+	}
+
+	air, err := aq.GetAirQuality(location.Lat, location.Lon)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		return ui.PrintJSON(ui.NewAirQualityDocument(location, air), output == OutputNDJSON)
+	}
+	ui.DisplayAirQuality(location, air)
+	return nil
+}
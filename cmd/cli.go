@@ -0,0 +1,529 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+)
+
+// App is a command-line program made of subcommands. Flags may appear before,
+// between or after the positional arguments, as --name value, --name=value or
+// with a single dash.
+type App struct {
+	Name    string
+	Summary string
+	// Commands are the subcommands selected by the first positional argument
+	Commands []*Command
+	// Default runs when the first positional argument is not a command name,
+	// which makes "uweather home" a shortcut for "uweather weather home"
+	Default *Command
+	// Flags registers the global flags accepted by every command
+	Flags func(fs *flag.FlagSet)
+	// Before runs after the flags are parsed and before the command
+	Before func(ctx *Context) error
+	// FlagValues suggests values for flags during shell completion, by flag
+	// name
+	FlagValues map[string]func() []string
+	// Footer is appended to the top-level help
+	Footer string
+	// Stdout receives the help output; nil means os.Stdout
+	Stdout io.Writer
+}
+
+// Command is one subcommand of an App. A command either runs itself or
+// dispatches to its own subcommands.
+type Command struct {
+	Name    string
+	Aliases []string
+	// Args describes the positional arguments in the help, e.g. "[label]"
+	Args    string
+	Summary string
+	// MinArgs and MaxArgs bound the number of positional arguments;
+	// MaxArgs < 0 means no upper bound
+	MinArgs, MaxArgs int
+	// Flags registers the command's own flags
+	Flags func(fs *flag.FlagSet)
+	Run   func(ctx *Context) error
+	// Complete suggests the next positional argument during shell
+	// completion, given the arguments typed so far
+	Complete func(args []string) []string
+	Commands []*Command
+}
+
+// Context is passed to a running command
+type Context struct {
+	// Path is the chain of commands that was selected, outermost first
+	Path []*Command
+	// Args are the positional arguments after the command names
+	Args []string
+
+	set map[string]bool
+}
+
+// IsSet reports whether a flag was given on the command line
+func (c *Context) IsSet(name string) bool {
+	return c.set[name]
+}
+
+// Bare reports whether the command was given neither positional arguments
+// nor flags, such as a plain "uweather"
+func (c *Context) Bare() bool {
+	return len(c.Args) == 0 && len(c.set) == 0
+}
+
+// Command returns the selected command
+func (c *Context) Command() *Command {
+	return c.Path[len(c.Path)-1]
+}
+
+// Alias registers short as another name for the already defined flag long
+func Alias(fs *flag.FlagSet, short, long string) {
+	f := fs.Lookup(long)
+	fs.Var(f.Value, short, f.Usage)
+}
+
+// Run parses args and runs the selected command
+func (a *App) Run(args []string) error {
+	if len(args) > 0 && args[0] == completeCommand {
+		a.printCompletions(args[1:])
+		return nil
+	}
+
+	path, args, err := a.resolve(args)
+	if err != nil {
+		return err
+	}
+	command := path[len(path)-1]
+
+	fs := a.flagSet(command)
+	help := fs.Bool("help", false, "show help")
+	Alias(fs, "h", "help")
+
+	ctx := &Context{Path: path, set: map[string]bool{}}
+	ctx.Args, err = parseFlags(fs, args)
+	if err != nil {
+		return Usagef("%v\nRun '%s' for usage.", err, a.helpCommand(path))
+	}
+	fs.Visit(func(f *flag.Flag) { ctx.set[f.Name] = true })
+
+	if *help {
+		if command == a.Default {
+			// "uweather --help" is the top-level help
+			path = nil
+		}
+		a.PrintHelp(path)
+		return nil
+	}
+
+	if command.Run == nil {
+		if len(ctx.Args) == 0 {
+			return Usagef("%s requires a subcommand\nRun '%s' for usage.", a.commandLine(path), a.helpCommand(path))
+		}
+		word := ctx.Args[0]
+		msg := fmt.Sprintf("unknown command %q for %s", word, a.commandLine(path))
+		if suggestion := suggest(word, commandNames(command.Commands)); suggestion != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		return Usagef("%s\nRun '%s' for usage.", msg, a.helpCommand(path))
+	}
+
+	if len(ctx.Args) < command.MinArgs || (command.MaxArgs >= 0 && len(ctx.Args) > command.MaxArgs) {
+		return Usagef("wrong number of arguments. Usage: %s", a.usageLine(path))
+	}
+
+	if a.Before != nil {
+		if err := a.Before(ctx); err != nil {
+			return err
+		}
+	}
+
+	err = command.Run(ctx)
+	if err != nil && command == a.Default && len(ctx.Args) > 0 && ExitCode(err) != ExitUsage {
+		// "uweather lcations" is looked up as a place; hint at the command
+		if suggestion := suggest(ctx.Args[0], commandNames(a.Commands)); suggestion != "" {
+			err = fmt.Errorf("%w (did you mean the %q command?)", err, suggestion)
+		}
+	}
+	return err
+}
+
+// resolve finds the selected command, skipping flags that appear before the
+// command names, and returns the arguments left for the command to parse
+func (a *App) resolve(args []string) ([]*Command, []string, error) {
+	var path []*Command
+	commands := a.Commands
+	rest := slices.Clone(args)
+
+	for {
+		i := firstPositional(a.flagSet(a.Default), rest)
+		if i < 0 {
+			break
+		}
+		next := findCommand(commands, rest[i])
+		if next == nil {
+			break
+		}
+		path = append(path, next)
+		commands = next.Commands
+		rest = slices.Delete(rest, i, i+1)
+		if next.Run != nil {
+			break
+		}
+	}
+
+	if len(path) == 0 {
+		if a.Default == nil {
+			return nil, nil, Usagef("command required\nRun '%s help' for usage.", a.Name)
+		}
+		path = []*Command{a.Default}
+	}
+	return path, rest, nil
+}
+
+// firstPositional returns the index of the first argument that is neither a
+// flag nor a flag value, or -1
+func firstPositional(fs *flag.FlagSet, args []string) int {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return -1
+		}
+		if !isFlag(arg) {
+			return i
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if f := fs.Lookup(name); f != nil && !hasValue && !isBoolFlag(f) {
+			i++
+		}
+	}
+	return -1
+}
+
+// parseFlags sets the flags found in args and returns the positional
+// arguments. Unlike flag.FlagSet.Parse it does not stop at the first
+// positional argument, and negative numbers such as -33.9,18.4 are positional.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(positional, args[i+1:]...), nil
+		}
+		if !isFlag(arg) {
+			positional = append(positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			msg := fmt.Sprintf("unknown flag %s", arg)
+			if suggestion := suggest(name, flagNames(fs)); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean --%s?)", suggestion)
+			}
+			return nil, fmt.Errorf("%s", msg)
+		}
+
+		if !hasValue {
+			if isBoolFlag(f) {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("invalid value %q for --%s", value, name)
+		}
+	}
+	return positional, nil
+}
+
+// ParseGlobalFlags sets the global flags found in args and skips everything
+// else, errors included. It lets the caller honor flags such as --output when
+// Run fails on a malformed command line before the command could run.
+func (a *App) ParseGlobalFlags(args []string) {
+	fs := a.flagSet(nil)
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		if !isFlag(arg) {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if !hasValue {
+			if isBoolFlag(f) {
+				value = "true"
+			} else if i+1 < len(args) {
+				i++
+				value = args[i]
+			} else {
+				return
+			}
+		}
+		fs.Set(name, value)
+	}
+}
+
+// isFlag reports whether arg looks like a flag rather than a value
+func isFlag(arg string) bool {
+	if len(arg) < 2 || arg[0] != '-' {
+		return false
+	}
+	c := arg[1]
+	return !(c >= '0' && c <= '9' || c == '.')
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// flagSet builds the flags accepted by command: its own and the global ones
+func (a *App) flagSet(command *Command) *flag.FlagSet {
+	fs := flag.NewFlagSet(a.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if command != nil && command.Flags != nil {
+		command.Flags(fs)
+	}
+	if a.Flags != nil {
+		a.Flags(fs)
+	}
+	return fs
+}
+
+// CommandNames returns the names and aliases of the top-level commands
+func (a *App) CommandNames() []string {
+	return commandNames(a.Commands)
+}
+
+func findCommand(commands []*Command, name string) *Command {
+	for _, c := range commands {
+		if c.Name == name || slices.Contains(c.Aliases, name) {
+			return c
+		}
+	}
+	return nil
+}
+
+func commandNames(commands []*Command) []string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.Name)
+		names = append(names, c.Aliases...)
+	}
+	return names
+}
+
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) { names = append(names, f.Name) })
+	return names
+}
+
+// suggest returns the candidate closest to word, if it is close enough to be
+// a likely typo
+func suggest(word string, candidates []string) string {
+	best, bestDistance := "", 0
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(word), strings.ToLower(c))
+		if best == "" || d < bestDistance {
+			best, bestDistance = c, d
+		}
+	}
+	if best == "" || bestDistance == 0 || bestDistance > max(1, len(best)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the number of insertions, deletions, substitutions
+// and transpositions of adjacent characters that turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+// commandLine returns the program and command names, e.g. "uweather config"
+func (a *App) commandLine(path []*Command) string {
+	parts := []string{a.Name}
+	for _, c := range path {
+		if c != a.Default {
+			parts = append(parts, c.Name)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// helpCommand returns the command that shows help for path
+func (a *App) helpCommand(path []*Command) string {
+	if len(path) == 0 || path[0] == a.Default {
+		return a.Name + " --help"
+	}
+	return a.commandLine(path) + " --help"
+}
+
+func (a *App) usageLine(path []*Command) string {
+	command := path[len(path)-1]
+	line := a.commandLine(path)
+	if command.Run == nil {
+		line += " [command]"
+	}
+	if command.Args != "" {
+		line += " " + command.Args
+	}
+	return line + " [flags]"
+}
+
+// Lookup returns the command path named by names, e.g. ["config", "set"]
+func (a *App) Lookup(names []string) ([]*Command, error) {
+	var path []*Command
+	commands := a.Commands
+	for _, name := range names {
+		c := findCommand(commands, name)
+		if c == nil {
+			msg := fmt.Sprintf("unknown command %q", name)
+			if suggestion := suggest(name, commandNames(commands)); suggestion != "" {
+				msg += fmt.Sprintf(" (did you mean %q?)", suggestion)
+			}
+			return nil, Usagef("%s", msg)
+		}
+		path = append(path, c)
+		commands = c.Commands
+	}
+	return path, nil
+}
+
+// PrintHelp writes the generated help of the command path; an empty path
+// prints the top-level help
+func (a *App) PrintHelp(path []*Command) {
+	w := a.Stdout
+	if w == nil {
+		w = os.Stdout
+	}
+
+	if len(path) == 0 {
+		fmt.Fprintf(w, "%s - %s\n\nUsage:\n", a.Name, a.Summary)
+		if a.Default != nil {
+			fmt.Fprintf(w, "  %s %s [flags]\n", a.Name, a.Default.Args)
+		}
+		fmt.Fprintf(w, "  %s [command] [flags]\n", a.Name)
+		printCommands(w, a.Commands)
+		if a.Default != nil {
+			printFlags(w, "Flags", a.Default.Flags)
+		}
+		printFlags(w, "Global flags", a.Flags)
+		fmt.Fprintf(w, "\nRun '%s help [command]' for more about a command.\n", a.Name)
+		if a.Footer != "" {
+			fmt.Fprintf(w, "\n%s", a.Footer)
+		}
+		return
+	}
+
+	command := path[len(path)-1]
+	fmt.Fprintf(w, "%s\n\nUsage:\n  %s\n", command.Summary, a.usageLine(path))
+	if len(command.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(command.Aliases, ", "))
+	}
+	printCommands(w, command.Commands)
+	printFlags(w, "Flags", command.Flags)
+	printFlags(w, "Global flags", a.Flags)
+}
+
+func printCommands(w io.Writer, commands []*Command) {
+	if len(commands) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nCommands:")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(tw, "  %s\t%s\n", c.Name, c.Summary)
+	}
+	tw.Flush()
+}
+
+// printFlags lists the flags defined by register, merging aliases of the
+// same flag into one line
+func printFlags(w io.Writer, title string, register func(*flag.FlagSet)) {
+	if register == nil {
+		return
+	}
+	own := flag.NewFlagSet("", flag.ContinueOnError)
+	register(own)
+
+	type entry struct {
+		names []string
+		flag  *flag.Flag
+	}
+	var entries []*entry
+	byValue := map[flag.Value]*entry{}
+	own.VisitAll(func(f *flag.Flag) {
+		if e, ok := byValue[f.Value]; ok {
+			e.names = append(e.names, f.Name)
+			return
+		}
+		e := &entry{names: []string{f.Name}, flag: f}
+		byValue[f.Value] = e
+		entries = append(entries, e)
+	})
+	if len(entries) == 0 {
+		return
+	}
+
+	fmt.Fprintf(w, "\n%s:\n", title)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, e := range entries {
+		// Short names first: -o, --output
+		slices.SortFunc(e.names, func(x, y string) int { return len(x) - len(y) })
+		names := make([]string, len(e.names))
+		for i, n := range e.names {
+			if len(n) == 1 {
+				names[i] = "-" + n
+			} else {
+				names[i] = "--" + n
+			}
+		}
+
+		placeholder, usage := flag.UnquoteUsage(e.flag)
+		if isBoolFlag(e.flag) {
+			placeholder = ""
+		}
+		if def := e.flag.DefValue; def != "" && def != "0" && def != "false" {
+			usage += fmt.Sprintf(" (default %s)", def)
+		}
+		fmt.Fprintf(tw, "  %s %s\t%s\n", strings.Join(names, ", "), placeholder, usage)
+	}
+	tw.Flush()
+}
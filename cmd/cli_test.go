@@ -0,0 +1,292 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// run records what a command of the test app was called with
+type run struct {
+	command string
+	args    []string
+	bare    bool
+	days    int
+	label   string
+	json    bool
+}
+
+// newTestApp builds an app shaped like uweather's: a default weather command,
+// global flags and a command group with subcommands. The flags are parsed
+// into got.
+func newTestApp(got *run) *App {
+	record := func(ctx *Context) error {
+		got.command, got.args, got.bare = ctx.Command().Name, ctx.Args, ctx.Bare()
+		return nil
+	}
+
+	weather := &Command{
+		Name:    "weather",
+		Args:    "[label]",
+		MaxArgs: 1,
+		Flags: func(fs *flag.FlagSet) {
+			fs.IntVar(&got.days, "days", 0, "forecast days")
+			Alias(fs, "d", "days")
+		},
+		Run: record,
+	}
+	return &App{
+		Name: "uweather",
+		Commands: []*Command{
+			weather,
+			{
+				Name:    "locations",
+				Aliases: []string{"ls"},
+				Commands: []*Command{
+					{
+						Name:    "add",
+						Args:    "<city>",
+						MinArgs: 1,
+						MaxArgs: 1,
+						Flags: func(fs *flag.FlagSet) {
+							fs.StringVar(&got.label, "label", "", "label to save the city as")
+						},
+						Run: record,
+					},
+					{Name: "remove", Args: "<label>", MinArgs: 1, MaxArgs: 1, Run: record},
+				},
+			},
+		},
+		Default: weather,
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&got.json, "json", false, "print JSON")
+		},
+		Stdout: io.Discard,
+	}
+}
+
+func TestAppRun(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    run
+		wantErr string
+	}{
+		{
+			name: "no arguments",
+			args: nil,
+			want: run{command: "weather", bare: true},
+		},
+		{
+			name: "only a flag",
+			args: []string{"--json"},
+			want: run{command: "weather", json: true},
+		},
+		{
+			name: "default command",
+			args: []string{"home"},
+			want: run{command: "weather", args: []string{"home"}},
+		},
+		{
+			name: "negative coordinates are positional",
+			args: []string{"-33.9,18.4"},
+			want: run{command: "weather", args: []string{"-33.9,18.4"}},
+		},
+		{
+			name: "negative number as a flag value",
+			args: []string{"--days", "-1", "home"},
+			want: run{command: "weather", args: []string{"home"}, days: -1},
+		},
+		{
+			name: "flag with equals",
+			args: []string{"home", "--days=5"},
+			want: run{command: "weather", args: []string{"home"}, days: 5},
+		},
+		{
+			name: "single dash alias",
+			args: []string{"-d", "2", "home"},
+			want: run{command: "weather", args: []string{"home"}, days: 2},
+		},
+		{
+			name: "global bool flag",
+			args: []string{"weather", "home", "--json"},
+			want: run{command: "weather", args: []string{"home"}, json: true},
+		},
+		{
+			name: "flags before the command names",
+			args: []string{"--json", "locations", "add", "Paris", "--label", "p"},
+			want: run{command: "add", args: []string{"Paris"}, label: "p", json: true},
+		},
+		{
+			name: "command alias",
+			args: []string{"ls", "remove", "home"},
+			want: run{command: "remove", args: []string{"home"}},
+		},
+		{
+			name: "double dash ends the flags",
+			args: []string{"locations", "add", "--", "-weird"},
+			want: run{command: "add", args: []string{"-weird"}},
+		},
+		{
+			name:    "unknown flag",
+			args:    []string{"home", "--dasy", "3"},
+			wantErr: "unknown flag --dasy (did you mean --days?)",
+		},
+		{
+			name:    "flag without a value",
+			args:    []string{"home", "--days"},
+			wantErr: "flag --days needs a value",
+		},
+		{
+			name:    "invalid flag value",
+			args:    []string{"home", "--days", "three"},
+			wantErr: `invalid value "three" for --days`,
+		},
+		{
+			name:    "flag of another command",
+			args:    []string{"home", "--label", "x"},
+			wantErr: "unknown flag --label",
+		},
+		{
+			name:    "wrong number of arguments",
+			args:    []string{"home", "work"},
+			wantErr: "wrong number of arguments",
+		},
+		{
+			name:    "missing subcommand",
+			args:    []string{"locations"},
+			wantErr: "uweather locations requires a subcommand",
+		},
+		{
+			name:    "unknown subcommand",
+			args:    []string{"locations", "ad", "Paris"},
+			wantErr: `unknown command "ad" for uweather locations (did you mean "add"?)`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got run
+			err := newTestApp(&got).Run(tt.args)
+			if tt.wantErr != "" {
+				var usage *UsageError
+				if !errors.As(err, &usage) || ExitCode(err) != ExitUsage {
+					t.Fatalf("err = %v with exit code %d, want a usage error", err, ExitCode(err))
+				}
+				if !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("err = %q, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ran %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAppRunSuggestsCommand(t *testing.T) {
+	app := newTestApp(new(run))
+	app.Default.Run = func(ctx *Context) error {
+		return fmt.Errorf("%w: %s", storage.ErrLocationNotFound, ctx.Args[0])
+	}
+
+	err := app.Run([]string{"locatoins"})
+	if ExitCode(err) != ExitNotFound {
+		t.Fatalf("err = %v with exit code %d, want %d", err, ExitCode(err), ExitNotFound)
+	}
+	if !strings.HasSuffix(err.Error(), `(did you mean the "locations" command?)`) {
+		t.Errorf("err = %q lacks the command suggestion", err)
+	}
+}
+
+func TestParseGlobalFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"--json", "--days", "abc", "home"}, true},
+		{[]string{"home", "--dasy", "3", "--json=true"}, true},
+		{[]string{"locations", "add", "-json"}, true},
+		{[]string{"home", "--days", "abc"}, false},
+		{[]string{"home", "--", "--json"}, false},
+	}
+
+	for _, tt := range tests {
+		var got run
+		newTestApp(&got).ParseGlobalFlags(tt.args)
+		if got.json != tt.want {
+			t.Errorf("ParseGlobalFlags(%q) set --json to %v, want %v", tt.args, got.json, tt.want)
+		}
+	}
+}
+
+func TestUsageErrorAsJSON(t *testing.T) {
+	args := []string{"home", "--days", "abc", "--json"}
+	var got run
+	app := newTestApp(&got)
+	err := app.Run(args)
+	if ExitCode(err) != ExitUsage {
+		t.Fatalf("err = %v, want a usage error", err)
+	}
+
+	// Parsing stopped at the bad value, before --json; main recovers it the
+	// same way
+	app.ParseGlobalFlags(args)
+	format := OutputText
+	if got.json {
+		format = OutputJSON
+	}
+
+	var buf bytes.Buffer
+	if code := ReportError(&buf, err, format); code != ExitUsage {
+		t.Errorf("exit code %d, want %d", code, ExitUsage)
+	}
+	var doc ui.ErrorDocument
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("error is not JSON: %v\n%s", err, buf.String())
+	}
+	want := ui.ErrorDocument{
+		SchemaVersion: ui.SchemaVersion,
+		Error:         "invalid value \"abc\" for --days\nRun 'uweather --help' for usage.",
+		Code:          "usage",
+		ExitCode:      ExitUsage,
+	}
+	if !reflect.DeepEqual(doc, want) {
+		t.Errorf("got %+v, want %+v", doc, want)
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	candidates := []string{"locations", "add", "remove", "days"}
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"lcations", "locations"},
+		{"locatoins", "locations"},
+		{"LOCATIONS", ""},
+		{"dasy", "days"},
+		{"ad", "add"},
+		{"forecast", ""},
+		{"x", ""},
+	}
+
+	for _, tt := range tests {
+		if got := suggest(tt.word, candidates); got != tt.want {
+			t.Errorf("suggest(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/cache"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+)
+
+// Environment variables that override the API base URLs from the config file
+const (
+	EnvForecastURL         = "UWEATHER_FORECAST_URL"
+	EnvGeocodingURL        = "UWEATHER_GEOCODING_URL"
+	EnvArchiveURL          = "UWEATHER_ARCHIVE_URL"
+	EnvAirQualityURL       = "UWEATHER_AIR_QUALITY_URL"
+	EnvMarineURL           = "UWEATHER_MARINE_URL"
+	EnvReverseGeocodingURL = "UWEATHER_REVERSE_GEOCODING_URL"
+)
+
+// CacheMode selects how the response cache is used
+type CacheMode int
+
+const (
+	// CacheDefault serves fresh entries from the cache
+	CacheDefault CacheMode = iota
+	// CacheOff bypasses the cache entirely (--no-cache)
+	CacheOff
+	// CacheRefresh ignores cached entries but stores new ones (--refresh)
+	CacheRefresh
+)
+
+// NewProvider builds the provider used by the commands: the API client,
+// wrapped in the on-disk cache unless mode is CacheOff. timeout bounds every
+// API call, retries included.
+// Environment variables take precedence over the "api" section of the config.
+func NewProvider(mode CacheMode, timeout time.Duration) (api.Provider, error) {
+	data, err := storage.LoadLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	client := newClient(data, api.WithTimeout(timeout))
+	if mode == CacheOff {
+		return client, nil
+	}
+
+	ttl := cache.DefaultTTL
+	if data.Preferences.CacheTTL != "" {
+		ttl, err = time.ParseDuration(data.Preferences.CacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cache_ttl preference %q: %w", data.Preferences.CacheTTL, err)
+		}
+	}
+
+	dir, err := storage.GetCachePath()
+	if err != nil {
+		return nil, err
+	}
+
+	provider := cache.New(client, dir, ttl)
+	provider.SetRefresh(mode == CacheRefresh)
+	provider.SetLanguage(data.Preferences.Language)
+	return provider, nil
+}
+
+// NewReverseGeocoder builds the reverse geocoder used to name raw
+// coordinates. The "reverse_geocoder" setting selects the backend: "nominatim"
+// (the default) falls back to the offline list of places when it fails,
+// "offline" never touches the network.
+func NewReverseGeocoder(timeout time.Duration) (api.ReverseGeocoder, error) {
+	data, err := storage.LoadLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	offline := api.NewOfflineGeocoder()
+
+	backend, baseURL := "", ""
+	if data.API != nil {
+		backend, baseURL = data.API.ReverseGeocoder, data.API.ReverseGeocodingURL
+	}
+	if env := os.Getenv(EnvReverseGeocodingURL); env != "" {
+		baseURL = env
+	}
+
+	switch backend {
+	case "", "nominatim":
+		nominatim := api.NewNominatim(baseURL)
+		nominatim.SetLanguage(data.Preferences.Language)
+		nominatim.SetTimeout(timeout)
+		return api.ReverseChain{nominatim, offline}, nil
+	case "offline":
+		return offline, nil
+	}
+	return nil, fmt.Errorf("unknown reverse geocoder %q (use nominatim or offline)", backend)
+}
+
+// Flush waits for any background work started by the provider, such as
+// refreshing stale cache entries
+func Flush(provider api.Provider) {
+	if w, ok := provider.(interface{ Wait() }); ok {
+		w.Wait()
+	}
+}
+
+func newClient(data *models.LocationsData, extra ...api.Option) *api.Client {
+	opts := extra
+	if data.API != nil {
+		opts = append(opts,
+			api.WithForecastURL(data.API.ForecastURL),
+			api.WithGeocodingURL(data.API.GeocodingURL),
+			api.WithArchiveURL(data.API.ArchiveURL),
+			api.WithAirQualityURL(data.API.AirQualityURL),
+			api.WithMarineURL(data.API.MarineURL))
+	}
+	opts = append(opts,
+		api.WithForecastURL(os.Getenv(EnvForecastURL)),
+		api.WithGeocodingURL(os.Getenv(EnvGeocodingURL)),
+		api.WithArchiveURL(os.Getenv(EnvArchiveURL)),
+		api.WithAirQualityURL(os.Getenv(EnvAirQualityURL)),
+		api.WithMarineURL(os.Getenv(EnvMarineURL)),
+		api.WithLanguage(data.Preferences.Language))
+
+	return api.NewClient(opts...)
+}
@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/ugur-claw/uweather/api"
 	"github.com/ugur-claw/uweather/models"
@@ -9,17 +13,36 @@ import (
 	"github.com/ugur-claw/uweather/ui"
 )
 
+// reservedLabels are the command names, which "uweather <label>" would run
+// instead of showing the location
+var reservedLabels []string
+
+// SetReservedLabels sets the names that cannot be used as labels
+func SetReservedLabels(names []string) {
+	reservedLabels = names
+}
+
+// checkLabel rejects a new label that is also a command name
+func checkLabel(label string) error {
+	if slices.Contains(reservedLabels, label) {
+		return Usagef("label %q is a command name; 'uweather %s' would run the command. Choose another label", label, label)
+	}
+	return nil
+}
+
 // AddCommand adds a new city location
-func AddCommand(city, label string) error {
+func AddCommand(geocoder api.Geocoder, city, label string, sel CityOptions, output OutputFormat) error {
 	if city == "" {
 		return fmt.Errorf("city name is required")
 	}
 	if label == "" {
 		return fmt.Errorf("label is required")
 	}
+	if err := checkLabel(label); err != nil {
+		return err
+	}
 
-	client := api.NewClient()
-	result, err := client.Geocoding(city)
+	result, err := resolveCity(geocoder, city, sel)
 	if err != nil {
 		return err
 	}
@@ -29,6 +52,14 @@ func AddCommand(city, label string) error {
 		return err
 	}
 
+	if output.IsJSON() {
+		location, err := storage.GetLocation(label)
+		if err != nil {
+			return err
+		}
+		return ui.PrintJSON(ui.LocationDocument{SchemaVersion: ui.SchemaVersion, Action: "added", Location: *location}, output == OutputNDJSON)
+	}
+
 	fmt.Printf("Added: %s (%s, %.4f, %.4f) with label '%s'\n",
 		result.Name, result.Country, result.Latitude, result.Longitude, label)
 
@@ -36,27 +67,52 @@ func AddCommand(city, label string) error {
 }
 
 // RemoveCommand removes a city by label
-func RemoveCommand(label string) error {
+func RemoveCommand(label string, output OutputFormat) error {
 	if label == "" {
 		return fmt.Errorf("label is required")
 	}
 
-	err := storage.RemoveLocation(label)
+	location, err := storage.GetLocation(label)
+	if err != nil {
+		return err
+	}
+
+	err = storage.RemoveLocation(label)
 	if err != nil {
 		return err
 	}
 
+	if output.IsJSON() {
+		return ui.PrintJSON(ui.LocationDocument{SchemaVersion: ui.SchemaVersion, Action: "removed", Location: *location}, output == OutputNDJSON)
+	}
+
 	fmt.Printf("Removed: %s\n", label)
 	return nil
 }
 
 // ListCommand lists all saved locations
-func ListCommand() error {
+func ListCommand(output OutputFormat) error {
 	locations, defaultLabel, err := storage.ListLocations()
 	if err != nil {
 		return err
 	}
 
+	if output.IsJSON() {
+		entries := make([]ui.LocationEntry, 0, len(locations))
+		for _, loc := range locations {
+			entries = append(entries, ui.LocationEntry{Location: loc, IsDefault: loc.Label == defaultLabel})
+		}
+		if output == OutputNDJSON {
+			for _, entry := range entries {
+				if err := ui.PrintJSON(entry, true); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return ui.PrintJSON(ui.LocationsDocument{SchemaVersion: ui.SchemaVersion, Default: defaultLabel, Locations: entries}, false)
+	}
+
 	if len(locations) == 0 {
 		fmt.Println("No locations saved. Add a location with 'uweather add [city] --label [label]'")
 		return nil
@@ -69,9 +125,18 @@ func ListCommand() error {
 		if loc.Label == defaultLabel {
 			marker = "*"
 		}
-		fmt.Printf("%s %s -> %s, %s\n", marker, loc.Label, loc.City, loc.Country)
+		fmt.Printf("%s %s -> %s, %s", marker, loc.Label, loc.City, loc.Country)
+		if len(loc.Tags) > 0 {
+			fmt.Printf("  [%s]", strings.Join(loc.Tags, ", "))
+		}
+		fmt.Println()
 	}
 	fmt.Println("\n* = default location")
+	for _, loc := range locations {
+		if slices.Contains(reservedLabels, loc.Label) {
+			fmt.Fprintf(os.Stderr, "Warning: label '%s' is also a command; show it with 'uweather weather %s' or rename it\n", loc.Label, loc.Label)
+		}
+	}
 
 	return nil
 }
@@ -91,8 +156,153 @@ func DefaultCommand(label string) error {
 	return nil
 }
 
+// RenameCommand changes the label of a saved location
+func RenameCommand(oldLabel, newLabel string, output OutputFormat) error {
+	if oldLabel == "" || newLabel == "" {
+		return fmt.Errorf("old and new label are required")
+	}
+	if err := checkLabel(newLabel); err != nil {
+		return err
+	}
+
+	err := storage.RenameLocation(oldLabel, newLabel)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		location, err := storage.GetLocation(newLabel)
+		if err != nil {
+			return err
+		}
+		return ui.PrintJSON(ui.LocationDocument{SchemaVersion: ui.SchemaVersion, Action: "renamed", Location: *location}, output == OutputNDJSON)
+	}
+
+	fmt.Printf("Renamed: %s -> %s\n", oldLabel, newLabel)
+	return nil
+}
+
+// LocationChanges holds the fields changed by EditCommand; nil fields are
+// left as they are
+type LocationChanges struct {
+	City     *string
+	Country  *string
+	Timezone *string // "" or "auto" uses the zone of the coordinates
+	Lat, Lon *float64
+}
+
+// EditCommand changes the city name, coordinates, country or timezone of a
+// saved location
+func EditCommand(label string, changes LocationChanges, output OutputFormat) error {
+	if label == "" {
+		return fmt.Errorf("label is required")
+	}
+	if changes == (LocationChanges{}) {
+		return Usagef("nothing to change. Use --city, --lat/--lon, --country or --timezone")
+	}
+	if (changes.Lat == nil) != (changes.Lon == nil) {
+		return Usagef("--lat and --lon must be given together")
+	}
+	if changes.Lat != nil {
+		if err := ValidateCoordinates(*changes.Lat, *changes.Lon); err != nil {
+			return err
+		}
+	}
+	if changes.City != nil && *changes.City == "" {
+		return Usagef("city name cannot be empty")
+	}
+	if changes.Timezone != nil {
+		if *changes.Timezone == "auto" {
+			*changes.Timezone = ""
+		}
+		if _, err := time.LoadLocation(*changes.Timezone); err != nil {
+			return Usagef("unknown timezone %q (use an IANA name such as Europe/Istanbul)", *changes.Timezone)
+		}
+	}
+
+	err := storage.UpdateLocation(label, func(loc *models.Location) error {
+		if changes.City != nil {
+			loc.City = *changes.City
+		}
+		if changes.Country != nil {
+			loc.Country = *changes.Country
+		}
+		if changes.Timezone != nil {
+			loc.Timezone = *changes.Timezone
+		}
+		if changes.Lat != nil {
+			loc.Lat, loc.Lon = *changes.Lat, *changes.Lon
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	location, err := storage.GetLocation(label)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		return ui.PrintJSON(ui.LocationDocument{SchemaVersion: ui.SchemaVersion, Action: "updated", Location: *location}, output == OutputNDJSON)
+	}
+
+	fmt.Printf("Updated: %s -> %s, %s (%.4f, %.4f)", label, location.City, location.Country, location.Lat, location.Lon)
+	if location.Timezone != "" {
+		fmt.Printf(" %s", location.Timezone)
+	}
+	fmt.Println()
+	return nil
+}
+
+// MoveCommand moves a saved location before or after another one, which sets
+// the order of the multi-location views
+func MoveCommand(label, anchor string, after bool, output OutputFormat) error {
+	if label == "" || anchor == "" {
+		return fmt.Errorf("label and target label are required")
+	}
+
+	err := storage.MoveLocation(label, anchor, after)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		return ListCommand(output)
+	}
+
+	position := "before"
+	if after {
+		position = "after"
+	}
+	fmt.Printf("Moved: %s %s %s\n", label, position, anchor)
+	return nil
+}
+
+// WeatherOptions holds the flags shared by the weather commands
+type WeatherOptions struct {
+	Days    int    // 0 uses the "days" preference
+	Past    int    // days before today to show as well
+	Units   string // units specification from --units; empty uses the preference
+	Output  OutputFormat
+	Hourly  bool // show the hour-by-hour view instead of the daily one
+	Hours   int  // number of hours in the hourly view
+	Details bool // add pressure, UV index and the like to the current conditions
+	AQI     bool // add the air quality to the current conditions
+	City    CityOptions
+}
+
+// dailyRows is the number of days in a lookup, past days included
+func (o WeatherOptions) dailyRows() int {
+	return o.Past + o.Days
+}
+
+// DefaultHours is the length of the hourly view when --hours is not given
+const DefaultHours = 24
+
 // WeatherCommand fetches and displays weather for a label or default
-func WeatherCommand(label string, days int) error {
+func WeatherCommand(provider api.WeatherProvider, label string, opts WeatherOptions) error {
 	var location *models.Location
 	var err error
 
@@ -110,31 +320,38 @@ func WeatherCommand(label string, days int) error {
 		}
 	}
 
-	client := api.NewClient()
-	weather, err := client.GetWeather(location.Lat, location.Lon, days)
+	opts, units, err := resolveWeatherOptions(opts)
 	if err != nil {
 		return err
 	}
 
-	// Display weather
-	ui.DisplayWeather(location, weather, days)
+	forecast := forecastOptions(opts, units)
+	forecast.Timezone = location.Timezone
+	weather, err := provider.GetWeather(location.Lat, location.Lon, forecast)
+	if err != nil {
+		return err
+	}
 
-	return nil
+	return displayWeather(provider, location, weather, units, opts)
 }
 
 // WeatherByCityCommand fetches weather for a city without saving
-func WeatherByCityCommand(city string, days int) error {
+func WeatherByCityCommand(provider api.Provider, city string, opts WeatherOptions) error {
 	if city == "" {
 		return fmt.Errorf("city name is required")
 	}
 
-	client := api.NewClient()
-	result, err := client.Geocoding(city)
+	opts, units, err := resolveWeatherOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	result, err := resolveCity(provider, city, opts.City)
 	if err != nil {
 		return err
 	}
 
-	weather, err := client.GetWeather(result.Latitude, result.Longitude, days)
+	weather, err := provider.GetWeather(result.Latitude, result.Longitude, forecastOptions(opts, units))
 	if err != nil {
 		return err
 	}
@@ -146,14 +363,88 @@ func WeatherByCityCommand(city string, days int) error {
 		Lon:     result.Longitude,
 	}
 
-	ui.DisplayWeather(location, weather, days)
+	return displayWeather(provider, location, weather, units, opts)
+}
 
+// forecastOptions builds the API request for a weather command
+func forecastOptions(opts WeatherOptions, units models.Units) api.ForecastOptions {
+	forecast := api.ForecastOptions{Days: opts.Days, PastDays: opts.Past, Units: units}
+	if opts.Hourly {
+		forecast.Hourly = api.DetailedHourly
+		// Cover the rest of today plus the requested number of hours
+		forecast.Days = max(forecast.Days, opts.Hours/24+2)
+	}
+	return forecast
+}
+
+// displayWeather renders a weather lookup in the requested output format
+func displayWeather(provider api.WeatherProvider, location *models.Location, weather *models.WeatherResponse, units models.Units, opts WeatherOptions) error {
+	if opts.Output.IsJSON() {
+		doc := ui.NewWeatherDocument(location, weather, opts.dailyRows(), units)
+		if opts.Hourly {
+			doc.Hourly = ui.NewHourlyDocuments(weather, opts.Hours)
+		}
+		if air := airQuality(provider, location, opts); air != nil {
+			doc.AirQuality = ui.NewAirQualityValues(air)
+		}
+		return ui.PrintJSON(doc, opts.Output == OutputNDJSON)
+	}
+
+	if opts.Hourly {
+		ui.DisplayHourly(location, weather, opts.Hours, units)
+		return nil
+	}
+
+	view := ui.WeatherView{Days: opts.dailyRows(), Details: opts.Details}
+	if view.Days == 1 {
+		view.AirQuality = airQuality(provider, location, opts)
+	}
+	ui.DisplayWeather(location, weather, units, view)
 	return nil
 }
 
-// EnsureConfigDir ensures the config directory exists
-func EnsureConfigDirCommand() error {
-	return storage.EnsureConfigDir()
+// airQuality fetches the air quality shown next to the weather when --aqi or
+// the show_aqi preference asks for it. A failure only costs that line, so it
+// is reported as a warning.
+func airQuality(provider api.WeatherProvider, location *models.Location, opts WeatherOptions) *models.AirQuality {
+	aq, ok := provider.(api.AirQualityProvider)
+	if !opts.AQI || !ok {
+		return nil
+	}
+	air, err := aq.GetAirQuality(location.Lat, location.Lon)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: air quality: %v\n", err)
+		return nil
+	}
+	return air
+}
+
+// UnitsCommand shows the preferred units, or stores a new preference
+func UnitsCommand(spec string) error {
+	if spec == "" {
+		_, units, err := resolveWeatherOptions(WeatherOptions{})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Units: %s (%s, %s, %s, %s)\n", units, units.TemperatureSymbol(), units.WindSymbol(), units.PrecipitationSymbol(), units.LengthSymbol())
+		return nil
+	}
+
+	units, err := models.ParseUnits(spec)
+	if err != nil {
+		return err
+	}
+
+	err = storage.UpdatePreferences(func(prefs *models.Preferences) error {
+		prefs.Units = units.String()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Units set to: %s\n", units)
+	return nil
 }
 
 // GetDefaultLabel returns the default label
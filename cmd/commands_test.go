@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/api/apitest"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// useTempHome keeps the config of a test in its own directory, with a
+// "home" location saved as the default
+func useTempHome(t *testing.T) {
+	t.Helper()
+	storage.SetHome(t.TempDir())
+	t.Cleanup(func() { storage.SetHome("") })
+
+	if err := storage.AddLocation("home", "Istanbul", 41.01, 28.95, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.SetDefaultLocation("home"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// captureStdout returns what f prints to stdout
+func captureStdout(t *testing.T, f func() error) (string, error) {
+	t.Helper()
+	return capture(t, &os.Stdout, f)
+}
+
+// captureStderr returns what f prints to stderr
+func captureStderr(t *testing.T, f func() error) (string, error) {
+	t.Helper()
+	return capture(t, &os.Stderr, f)
+}
+
+// capture returns what f writes to the file *target, stdout or stderr
+func capture(t *testing.T, target **os.File, f func() error) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved := *target
+	*target = w
+	defer func() { *target = saved }()
+
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+	err = f()
+	w.Close()
+	return string(<-done), err
+}
+
+func TestWeatherCommandJSON(t *testing.T) {
+	useTempHome(t)
+	s := apitest.NewServer()
+	defer s.Close()
+
+	for _, label := range []string{"home", ""} {
+		out, err := captureStdout(t, func() error {
+			return WeatherCommand(s.Client(), label, WeatherOptions{Days: 3, Output: OutputJSON})
+		})
+		if err != nil {
+			t.Fatalf("label %q: %v", label, err)
+		}
+
+		var doc ui.WeatherDocument
+		if err := json.Unmarshal([]byte(out), &doc); err != nil {
+			t.Fatalf("label %q: output is not a weather document: %v\n%s", label, err, out)
+		}
+		if doc.SchemaVersion != ui.SchemaVersion || doc.Location.Label != "home" || doc.Location.City != "Istanbul" {
+			t.Errorf("label %q: got %+v", label, doc)
+		}
+		if doc.Current.Temperature != 18.4 || len(doc.Daily) != 3 {
+			t.Errorf("label %q: current %+v with %d days", label, doc.Current, len(doc.Daily))
+		}
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(s *apitest.Server) error
+		want int
+	}{
+		{
+			name: "unknown label",
+			run: func(s *apitest.Server) error {
+				return WeatherCommand(s.Client(), "nowhere", WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitNotFound,
+		},
+		{
+			name: "unknown city",
+			run: func(s *apitest.Server) error {
+				return WeatherByCityCommand(s.Client(), "Atlantis", WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitNotFound,
+		},
+		{
+			name: "ambiguous city",
+			run: func(s *apitest.Server) error {
+				return WeatherByCityCommand(s.Client(), "Paris", WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitAmbiguous,
+		},
+		{
+			name: "pick out of range",
+			run: func(s *apitest.Server) error {
+				return WeatherByCityCommand(s.Client(), "Paris", WeatherOptions{Output: OutputJSON, City: CityOptions{Pick: 99}})
+			},
+			want: ExitUsage,
+		},
+		{
+			name: "label exists",
+			run: func(s *apitest.Server) error {
+				return AddCommand(s.Client(), "Istanbul", "home", CityOptions{}, OutputJSON)
+			},
+			want: ExitConflict,
+		},
+		{
+			name: "coordinates not a number",
+			run: func(s *apitest.Server) error {
+				return WeatherByCoordinatesCommand(s.Client(), nil, 41, math.NaN(), WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitUsage,
+		},
+		{
+			name: "rate limited",
+			run: func(s *apitest.Server) error {
+				s.Fail("/v1/forecast", http.StatusTooManyRequests, "Minutely API request limit exceeded")
+				return WeatherCommand(s.Client(api.WithRetries(0)), "home", WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitRateLimit,
+		},
+		{
+			name: "api error",
+			run: func(s *apitest.Server) error {
+				s.Fail("/v1/forecast", http.StatusBadRequest, "Cannot initialize WeatherVariable from invalid String value")
+				return WeatherCommand(s.Client(), "home", WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitAPI,
+		},
+		{
+			name: "network error",
+			run: func(s *apitest.Server) error {
+				client := s.Client(api.WithRetries(0))
+				s.Close()
+				return WeatherCommand(client, "home", WeatherOptions{Output: OutputJSON})
+			},
+			want: ExitNetwork,
+		},
+		{
+			name: "inland marine lookup",
+			run: func(s *apitest.Server) error {
+				s.Handle("/v1/marine", func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte(`{"current":{"time":"2026-10-12T14:00","wave_height":null}}`))
+				})
+				return MarineCommand(s.Client(), "home", MarineOptions{Output: OutputJSON})
+			},
+			want: ExitNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			s := apitest.NewServer()
+			defer s.Close()
+
+			_, err := captureStdout(t, func() error { return tt.run(s) })
+			if got := ExitCode(err); got != tt.want {
+				t.Errorf("ExitCode(%v) = %d, want %d", err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	ptr := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name     string
+		label    string
+		changes  LocationChanges
+		wantCode int
+		want     models.Location
+	}{
+		{
+			name:    "city and timezone",
+			label:   "home",
+			changes: LocationChanges{City: ptr("Kadıköy"), Timezone: ptr("Europe/Istanbul")},
+			want:    models.Location{Label: "home", City: "Kadıköy", Country: "Türkiye", Lat: 41.01, Lon: 28.95, Timezone: "Europe/Istanbul"},
+		},
+		{
+			name:    "coordinates and automatic timezone",
+			label:   "home",
+			changes: LocationChanges{Lat: num(-33.9), Lon: num(18.4), Country: ptr("South Africa"), Timezone: ptr("auto")},
+			want:    models.Location{Label: "home", City: "Istanbul", Country: "South Africa", Lat: -33.9, Lon: 18.4},
+		},
+		{name: "nothing to change", label: "home", wantCode: ExitUsage},
+		{name: "latitude alone", label: "home", changes: LocationChanges{Lat: num(40)}, wantCode: ExitUsage},
+		{name: "latitude out of range", label: "home", changes: LocationChanges{Lat: num(95), Lon: num(0)}, wantCode: ExitUsage},
+		{name: "empty city", label: "home", changes: LocationChanges{City: ptr("")}, wantCode: ExitUsage},
+		{name: "unknown timezone", label: "home", changes: LocationChanges{Timezone: ptr("Mars/Olympus")}, wantCode: ExitUsage},
+		{name: "unknown label", label: "beach", changes: LocationChanges{City: ptr("Bodrum")}, wantCode: ExitNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			_, err := captureStdout(t, func() error { return EditCommand(tt.label, tt.changes, OutputText) })
+			if got := ExitCode(err); got != tt.wantCode {
+				t.Fatalf("ExitCode(%v) = %d, want %d", err, got, tt.wantCode)
+			}
+			if tt.wantCode != ExitOK {
+				return
+			}
+			loc, err := storage.GetLocation(tt.label)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(*loc, tt.want) {
+				t.Errorf("got %+v, want %+v", *loc, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/ugur-claw/uweather/storage"
+)
+
+// completeCommand is the hidden command called by the completion scripts
+// with the words typed so far; it prints one candidate per line
+const completeCommand = "__complete"
+
+// Shells supported by CompletionCommand
+var Shells = []string{"bash", "zsh", "fish"}
+
+const bashCompletion = `# bash completion for %[1]s
+# Load with: source <(%[1]s completion bash)
+_%[1]s() {
+    local cur=${COMP_WORDS[COMP_CWORD]}
+    local IFS=$'\n'
+    COMPREPLY=($(compgen -W "$(%[1]s __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null)" -- "$cur"))
+}
+complete -F _%[1]s %[1]s
+`
+
+const zshCompletion = `#compdef %[1]s
+# zsh completion for %[1]s
+# Load with: source <(%[1]s completion zsh), or save as _%[1]s in $fpath
+_%[1]s() {
+    local out
+    out=$(%[1]s __complete "${(@)words[2,CURRENT]}" 2>/dev/null)
+    [[ -n $out ]] && compadd -- "${(@f)out}"
+}
+if [[ $funcstack[1] == _%[1]s ]]; then
+    _%[1]s "$@"
+else
+    compdef _%[1]s %[1]s
+fi
+`
+
+const fishCompletion = `# fish completion for %[1]s
+# Load with: %[1]s completion fish | source
+function __%[1]s_complete
+    set -l words (commandline -opc) (commandline -ct)
+    %[1]s __complete $words[2..-1] 2>/dev/null
+end
+complete -c %[1]s -f -a '(__%[1]s_complete)'
+`
+
+// CompletionCommand prints the completion script for shell
+func CompletionCommand(program, shell string) error {
+	var script string
+	switch shell {
+	case "bash":
+		script = bashCompletion
+	case "zsh":
+		script = zshCompletion
+	case "fish":
+		script = fishCompletion
+	default:
+		return Usagef("unknown shell %q (use %s)", shell, strings.Join(Shells, ", "))
+	}
+	fmt.Printf(script, program)
+	return nil
+}
+
+// CompleteLabels returns the saved location labels
+func CompleteLabels(args []string) []string {
+	locations, _, err := storage.ListLocations()
+	if err != nil {
+		return nil
+	}
+	labels := make([]string, len(locations))
+	for i, loc := range locations {
+		labels[i] = loc.Label
+	}
+	return labels
+}
+
+// printCompletions prints the candidates for the last of words, the word
+// under the cursor
+func (a *App) printCompletions(words []string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	for _, c := range a.Complete(words[:len(words)-1], words[len(words)-1]) {
+		fmt.Println(c)
+	}
+}
+
+// Complete returns the completion candidates for the word being typed, given
+// the words before it
+func (a *App) Complete(words []string, current string) []string {
+	path, rest, err := a.resolve(words)
+	if err != nil {
+		return nil
+	}
+	command := path[len(path)-1]
+
+	fs := a.flagSet(command)
+	// Flags given so far, such as --config-dir, affect the candidates
+	args, _ := parseFlags(fs, trimPending(fs, rest))
+
+	var candidates []string
+	switch f := pendingFlag(fs, rest); {
+	case f != nil:
+		// Aliases share the flag's Value, so -o completes like --output
+		fs.VisitAll(func(g *flag.Flag) {
+			if values := a.FlagValues[g.Name]; values != nil && g.Value == f.Value {
+				candidates = values()
+			}
+		})
+	case strings.HasPrefix(current, "-"):
+		fs.VisitAll(func(f *flag.Flag) {
+			if len(f.Name) > 1 {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		})
+	default:
+		implicit := command == a.Default && len(rest) == len(words)
+		if command.Run == nil {
+			candidates = names(command.Commands)
+		} else if implicit && len(args) == 0 {
+			// "uweather <TAB>" offers the commands next to the labels
+			candidates = names(a.Commands)
+		}
+		if command.Complete != nil && (command.MaxArgs < 0 || len(args) < command.MaxArgs) {
+			candidates = append(candidates, command.Complete(args)...)
+		}
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) && !slices.Contains(matches, c) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func names(commands []*Command) []string {
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.Name
+	}
+	return names
+}
+
+// pendingFlag returns the flag whose value is being typed: the last word is a
+// flag that takes a value and none was given yet
+func pendingFlag(fs *flag.FlagSet, words []string) *flag.Flag {
+	if len(words) == 0 {
+		return nil
+	}
+	last := words[len(words)-1]
+	if !isFlag(last) || strings.Contains(last, "=") {
+		return nil
+	}
+	f := fs.Lookup(strings.TrimLeft(last, "-"))
+	if f == nil || isBoolFlag(f) {
+		return nil
+	}
+	return f
+}
+
+// trimPending drops a trailing flag that still waits for its value
+func trimPending(fs *flag.FlagSet, words []string) []string {
+	if pendingFlag(fs, words) != nil {
+		return words[:len(words)-1]
+	}
+	return words
+}
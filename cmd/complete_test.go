@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newCompletionApp is the test app with labels and flag values to complete
+func newCompletionApp() *App {
+	app := newTestApp(new(run))
+	labels := func(args []string) []string { return []string{"home", "work", "hamburg"} }
+	app.Default.Complete = labels
+	app.Commands[1].Commands[1].Complete = labels
+	app.FlagValues = map[string]func() []string{
+		"days": func() []string { return []string{"1", "2", "3"} },
+	}
+	return app
+}
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		name    string
+		words   []string
+		current string
+		want    []string
+	}{
+		{"commands and labels", nil, "", []string{"weather", "locations", "home", "work", "hamburg"}},
+		{"prefix", nil, "h", []string{"home", "hamburg"}},
+		{"after a global flag", []string{"--json"}, "w", []string{"weather", "work"}},
+		{"subcommands", []string{"locations"}, "", []string{"add", "remove"}},
+		{"labels of a subcommand", []string{"ls", "remove"}, "w", []string{"work"}},
+		{"no more arguments", []string{"home"}, "", nil},
+		{"flag value", []string{"--days"}, "", []string{"1", "2", "3"}},
+		{"value of an alias", []string{"home", "-d"}, "", []string{"1", "2", "3"}},
+		{"flag names", nil, "--d", []string{"--days"}},
+		{"flags of a subcommand", []string{"locations", "add"}, "--", []string{"--json", "--label"}},
+		{"flag without values", []string{"locations", "add", "--label"}, "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newCompletionApp().Complete(tt.words, tt.current)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Complete(%q, %q) = %q, want %q", tt.words, tt.current, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompleteCommand(t *testing.T) {
+	out, err := captureStdout(t, func() error {
+		return newCompletionApp().Run([]string{completeCommand, "locations", "r"})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "remove\n" {
+		t.Errorf("printed %q, want one candidate per line", out)
+	}
+}
+
+func TestCompletionCommand(t *testing.T) {
+	tests := []struct {
+		shell   string
+		want    string
+		wantErr bool
+	}{
+		{shell: "bash", want: "complete -F _uweather uweather"},
+		{shell: "zsh", want: "compdef _uweather uweather"},
+		{shell: "fish", want: "complete -c uweather -f -a '(__uweather_complete)'"},
+		{shell: "powershell", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		out, err := captureStdout(t, func() error { return CompletionCommand("uweather", tt.shell) })
+		if tt.wantErr {
+			if ExitCode(err) != ExitUsage {
+				t.Errorf("%s: err = %v, want a usage error", tt.shell, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.shell, err)
+		}
+		if !strings.Contains(out, tt.want) || !strings.Contains(out, "uweather __complete") {
+			t.Errorf("%s script lacks %q:\n%s", tt.shell, tt.want, out)
+		}
+	}
+}
+
+func TestCompleteLabels(t *testing.T) {
+	useTempHome(t)
+	if got := CompleteLabels(nil); !reflect.DeepEqual(got, []string{"home"}) {
+		t.Errorf("CompleteLabels() = %q, want the saved labels", got)
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// ConfigMigrateCommand upgrades the config file to the current schema
+// version. With dryRun it only shows the steps and the resulting changes.
+func ConfigMigrateCommand(dryRun bool) error {
+	var plan *storage.MigrationPlan
+	var err error
+	if dryRun {
+		plan, err = storage.PlanMigration()
+	} else {
+		plan, err = storage.Migrate()
+	}
+	if err != nil {
+		return err
+	}
+
+	if !plan.NeedsMigration() {
+		fmt.Printf("%s is up to date (version %d)\n", plan.Path, plan.ToVersion)
+		return nil
+	}
+
+	fmt.Printf("%s: version %d -> %d\n", plan.Path, plan.FromVersion, plan.ToVersion)
+	for _, step := range plan.Steps {
+		fmt.Printf("  %s\n", step)
+	}
+	fmt.Println()
+	ui.PrintDiff(string(plan.Before), string(plan.After))
+
+	if dryRun {
+		fmt.Println("\nDry run: no changes written")
+	} else {
+		fmt.Printf("\nMigrated. The previous file is kept as %s.bak\n", plan.Path)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// ParseCoordinates parses "lat,lon", e.g. "41.01,28.97". It reports false
+// when s does not look like a coordinate pair at all.
+func ParseCoordinates(s string) (float64, float64, bool) {
+	latStr, lonStr, found := strings.Cut(s, ",")
+	if !found {
+		return 0, 0, false
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latStr), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonStr), 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lat, lon, true
+}
+
+// ValidateCoordinates checks that lat and lon are on the globe. NaN and the
+// infinities, which strconv accepts, are rejected.
+func ValidateCoordinates(lat, lon float64) error {
+	if math.IsNaN(lat) || math.IsInf(lat, 0) {
+		return Usagef("latitude %g is not a number", lat)
+	}
+	if math.IsNaN(lon) || math.IsInf(lon, 0) {
+		return Usagef("longitude %g is not a number", lon)
+	}
+	if lat < -90 || lat > 90 {
+		return Usagef("latitude %g is out of range (-90 to 90)", lat)
+	}
+	if lon < -180 || lon > 180 {
+		return Usagef("longitude %g is out of range (-180 to 180)", lon)
+	}
+	return nil
+}
+
+// AddCoordinatesCommand adds a location by raw coordinates. When name is
+// empty the display name comes from the reverse geocoder.
+func AddCoordinatesCommand(reverse api.ReverseGeocoder, name, label string, lat, lon float64, output OutputFormat) error {
+	if label == "" {
+		return fmt.Errorf("label is required")
+	}
+	if err := checkLabel(label); err != nil {
+		return err
+	}
+	if err := ValidateCoordinates(lat, lon); err != nil {
+		return err
+	}
+
+	place := coordinatesPlace(reverse, name, lat, lon)
+
+	err := storage.AddLocation(label, place.Name, lat, lon, place.Country)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		location, err := storage.GetLocation(label)
+		if err != nil {
+			return err
+		}
+		return ui.PrintJSON(ui.LocationDocument{SchemaVersion: ui.SchemaVersion, Action: "added", Location: *location}, output == OutputNDJSON)
+	}
+
+	fmt.Printf("Added: %s (%s, %.4f, %.4f) with label '%s'\n",
+		place.Name, place.Country, lat, lon, label)
+
+	return nil
+}
+
+// WeatherByCoordinatesCommand fetches weather for raw coordinates without
+// saving them
+func WeatherByCoordinatesCommand(provider api.WeatherProvider, reverse api.ReverseGeocoder, lat, lon float64, opts WeatherOptions) error {
+	if err := ValidateCoordinates(lat, lon); err != nil {
+		return err
+	}
+
+	opts, units, err := resolveWeatherOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	weather, err := provider.GetWeather(lat, lon, forecastOptions(opts, units))
+	if err != nil {
+		return err
+	}
+
+	place := coordinatesPlace(reverse, "", lat, lon)
+	location := &models.Location{
+		City:    place.Name,
+		Country: place.Country,
+		Lat:     lat,
+		Lon:     lon,
+	}
+
+	return displayWeather(provider, location, weather, units, opts)
+}
+
+// coordinatesPlace names a coordinate pair. An explicit name wins, then the
+// reverse geocoder, then the coordinates themselves.
+func coordinatesPlace(reverse api.ReverseGeocoder, name string, lat, lon float64) models.GeocodingResult {
+	place := models.GeocodingResult{Name: name, Latitude: lat, Longitude: lon}
+	if reverse == nil {
+		if place.Name == "" {
+			place.Name = fmt.Sprintf("%.4f, %.4f", lat, lon)
+		}
+		return place
+	}
+
+	result, err := reverse.ReverseGeocode(lat, lon)
+	if err != nil {
+		if place.Name == "" {
+			place.Name = fmt.Sprintf("%.4f, %.4f", lat, lon)
+		}
+		return place
+	}
+	if place.Name == "" {
+		place.Name = result.Name
+	}
+	place.Country = result.Country
+	return place
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// DashboardCommand shows the current conditions of every saved location, or
+// of the locations tagged with group, in one table. Locations that fail are
+// shown inline; the command still reports an error afterwards.
+func DashboardCommand(provider api.WeatherProvider, group string, opts WeatherOptions) error {
+	locations, defaultLabel, err := storage.ListLocations()
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		locations, err = storage.LocationsByTag(group)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(locations) == 0 {
+		if opts.Output.IsJSON() {
+			return ui.PrintJSON(ui.DashboardDocument{SchemaVersion: ui.SchemaVersion, Locations: []ui.DashboardEntry{}}, opts.Output == OutputNDJSON)
+		}
+		fmt.Println("No locations saved. Add a location with 'uweather add [city] --label [label]'")
+		return nil
+	}
+
+	opts.Days = 1
+	opts, units, err := resolveWeatherOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	results := fetchAll(provider, locations, forecastOptions(opts, units))
+
+	rows := make([]ui.DashboardRow, len(results))
+	failed := 0
+	var firstErr error
+	for i, result := range results {
+		rows[i] = ui.DashboardRow{
+			Location:  result.Location,
+			IsDefault: result.Location.Label == defaultLabel,
+			Weather:   result.Weather,
+			Err:       result.Err,
+		}
+		if result.Err != nil {
+			failed++
+			firstErr = cmp.Or(firstErr, result.Err)
+		}
+	}
+
+	switch opts.Output {
+	case OutputJSON:
+		doc := ui.DashboardDocument{SchemaVersion: ui.SchemaVersion, Default: defaultLabel, Units: units}
+		for _, row := range rows {
+			doc.Locations = append(doc.Locations, ui.NewDashboardEntry(row, units))
+		}
+		if err := ui.PrintJSON(doc, false); err != nil {
+			return err
+		}
+	case OutputNDJSON:
+		for _, row := range rows {
+			if err := ui.PrintJSON(ui.NewDashboardEntry(row, units), true); err != nil {
+				return err
+			}
+		}
+	default:
+		ui.DisplayDashboard(rows, units)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d locations failed: %w", failed, len(rows), firstErr)
+	}
+	return nil
+}
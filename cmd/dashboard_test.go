@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/api/apitest"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+func TestDashboardCommand(t *testing.T) {
+	useTempHome(t)
+	if err := storage.AddLocation("bad", "Nowhere", 95, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	s := apitest.NewServer()
+	defer s.Close()
+	s.Handle("/v1/forecast", failLatitude95)
+	client := s.Client(api.WithRetries(0))
+
+	// A failing location is reported inline, next to the others
+	out, err := captureStdout(t, func() error {
+		return DashboardCommand(client, "", WeatherOptions{Output: OutputJSON})
+	})
+	if ExitCode(err) != ExitAPI {
+		t.Errorf("err = %v, want an API error", err)
+	}
+	var doc ui.DashboardDocument
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not a dashboard document: %v\n%s", err, out)
+	}
+	if doc.Default != "home" || len(doc.Locations) != 2 {
+		t.Fatalf("got %+v", doc)
+	}
+	if home := doc.Locations[0]; !home.IsDefault || home.Current == nil || home.Error != "" {
+		t.Errorf("home = %+v", home)
+	}
+	if bad := doc.Locations[1]; bad.IsDefault || bad.Current != nil || !strings.Contains(bad.Error, "Latitude must be in range") {
+		t.Errorf("bad = %+v", bad)
+	}
+
+	out, _ = captureStdout(t, func() error {
+		return DashboardCommand(client, "", WeatherOptions{Output: OutputText})
+	})
+	for _, want := range []string{"* home", "Error: weather API returned status 400"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("table lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDashboardCommandGroup(t *testing.T) {
+	useTempHome(t)
+	if err := storage.AddLocation("work", "Ankara", 39.92, 32.85, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := storage.TagLocation("work", "offices"); err != nil {
+		t.Fatal(err)
+	}
+	s := apitest.NewServer()
+	defer s.Close()
+
+	out, err := captureStdout(t, func() error {
+		return DashboardCommand(s.Client(), "offices", WeatherOptions{Output: OutputNDJSON})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	var entry ui.DashboardEntry
+	if len(lines) != 1 || json.Unmarshal([]byte(lines[0]), &entry) != nil || entry.Label != "work" {
+		t.Errorf("got %q, want one line for work", lines)
+	}
+
+	if err := DashboardCommand(s.Client(), "nobody", WeatherOptions{Output: OutputJSON}); ExitCode(err) != ExitNotFound {
+		t.Errorf("unknown group: err = %v, want not found", err)
+	}
+}
+
+func TestCheckLabel(t *testing.T) {
+	SetReservedLabels([]string{"weather", "locations", "ls", "all"})
+	t.Cleanup(func() { SetReservedLabels(nil) })
+
+	tests := []struct {
+		label   string
+		wantErr bool
+	}{
+		{"home", false},
+		{"weather", true},
+		{"ls", true},
+		{"all", true},
+		{"all-offices", false},
+		{"Weather", false},
+	}
+	for _, tt := range tests {
+		if err := checkLabel(tt.label); (err != nil) != tt.wantErr || (err != nil && ExitCode(err) != ExitUsage) {
+			t.Errorf("checkLabel(%q) = %v, want an error: %v", tt.label, err, tt.wantErr)
+		}
+	}
+}
+
+func TestReservedLabels(t *testing.T) {
+	useTempHome(t)
+	SetReservedLabels([]string{"weather", "all"})
+	t.Cleanup(func() { SetReservedLabels(nil) })
+	s := apitest.NewServer()
+	defer s.Close()
+
+	if err := AddCommand(s.Client(), "Istanbul", "all", CityOptions{}, OutputJSON); ExitCode(err) != ExitUsage {
+		t.Errorf("add: err = %v, want a usage error", err)
+	}
+	if err := AddCoordinatesCommand(nil, "Istanbul", "weather", 41, 29, OutputJSON); ExitCode(err) != ExitUsage {
+		t.Errorf("add coordinates: err = %v, want a usage error", err)
+	}
+	if err := RenameCommand("home", "weather", OutputJSON); ExitCode(err) != ExitUsage {
+		t.Errorf("rename: err = %v, want a usage error", err)
+	}
+	if labels := CompleteLabels(nil); len(labels) != 1 || labels[0] != "home" {
+		t.Errorf("labels = %q, want only home", labels)
+	}
+
+	// A label saved before it became a command name is listed with a warning
+	if err := storage.AddLocation("all", "Ankara", 39.92, 32.85, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := captureStderr(t, func() error {
+		_, err := captureStdout(t, func() error { return ListCommand(OutputText) })
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stderr, "Warning: label 'all' is also a command") {
+		t.Errorf("stderr = %q, want a warning about 'all'", stderr)
+	}
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// CityOptions narrows down geocoding results when a city name is ambiguous
+type CityOptions struct {
+	Pick    int    // 1-based index into the matches (--pick); 0 asks on a terminal
+	Country string // ISO country code or country name (--country)
+}
+
+// AmbiguousCityError is returned when a city name matches several places
+// and there is no terminal to ask which one was meant
+type AmbiguousCityError struct {
+	Query      string
+	Candidates []models.GeocodingResult
+}
+
+func (e *AmbiguousCityError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%q matches %d places:\n", e.Query, len(e.Candidates))
+	for i, c := range e.Candidates {
+		fmt.Fprintf(&b, "  %d) %s\n", i+1, ui.FormatCandidate(c))
+	}
+	b.WriteString("Use --pick N or --country XX to choose one")
+	return b.String()
+}
+
+// resolveCity geocodes a city name and narrows the matches down to one,
+// asking the user when several remain and stdin is a terminal
+func resolveCity(geocoder api.Geocoder, city string, sel CityOptions) (*models.GeocodingResult, error) {
+	results, err := geocoder.GeocodingMulti(city)
+	if errors.Is(err, api.ErrCityNotFound) {
+		return nil, cityNotFound(err, city)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if sel.Country != "" {
+		results = filterByCountry(results, sel.Country)
+		if len(results) == 0 {
+			return nil, fmt.Errorf("%w: %s in %s", api.ErrCityNotFound, city, sel.Country)
+		}
+	}
+	if len(results) == 0 {
+		return nil, cityNotFound(fmt.Errorf("%w: %s", api.ErrCityNotFound, city), city)
+	}
+
+	if sel.Pick != 0 {
+		if sel.Pick < 1 || sel.Pick > len(results) {
+			return nil, Usagef("--pick %d is out of range, %q has %d matches", sel.Pick, city, len(results))
+		}
+		return &results[sel.Pick-1], nil
+	}
+
+	if len(results) == 1 {
+		return &results[0], nil
+	}
+
+	if !ui.IsInteractive() {
+		return nil, &AmbiguousCityError{Query: city, Candidates: results}
+	}
+
+	index, err := ui.PickCandidate(city, results)
+	if err != nil {
+		return nil, err
+	}
+	return &results[index], nil
+}
+
+// lookupLocation resolves a saved label or a city name, or the default
+// location when target is empty
+func lookupLocation(geocoder api.Geocoder, target string, sel CityOptions) (*models.Location, error) {
+	if target == "" {
+		return storage.GetDefaultLocation()
+	}
+	if location, err := storage.GetLocation(target); err == nil {
+		return location, nil
+	}
+
+	result, err := resolveCity(geocoder, target, sel)
+	if err != nil {
+		return nil, err
+	}
+	return &models.Location{
+		City:    result.Name,
+		Country: result.Country,
+		Lat:     result.Latitude,
+		Lon:     result.Longitude,
+	}, nil
+}
+
+// cityNotFound adds a hint to err when city looks like a typo of a saved
+// label or of the city of a saved location
+func cityNotFound(err error, city string) error {
+	locations, _, listErr := storage.ListLocations()
+	if listErr != nil {
+		return err
+	}
+	var candidates []string
+	for _, loc := range locations {
+		candidates = append(candidates, loc.Label, loc.City)
+	}
+	if suggestion := suggest(city, candidates); suggestion != "" {
+		return fmt.Errorf("%w (did you mean %q?)", err, suggestion)
+	}
+	return err
+}
+
+// filterByCountry keeps the results whose country code or name matches
+func filterByCountry(results []models.GeocodingResult, country string) []models.GeocodingResult {
+	filtered := make([]models.GeocodingResult, 0, len(results))
+	for _, r := range results {
+		if strings.EqualFold(r.CountryCode, country) || strings.EqualFold(r.Country, country) {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+var tagPattern = regexp.MustCompile(`^[\pL\pN_.-]+$`)
+
+// TagAddCommand adds tags to a saved location
+func TagAddCommand(label string, tags []string, output OutputFormat) error {
+	if err := validateTags(tags); err != nil {
+		return err
+	}
+	if err := storage.TagLocation(label, tags...); err != nil {
+		return err
+	}
+	return printTags(label, "tagged", output)
+}
+
+// TagRemoveCommand removes tags from a saved location
+func TagRemoveCommand(label string, tags []string, output OutputFormat) error {
+	if err := validateTags(tags); err != nil {
+		return err
+	}
+	if err := storage.UntagLocation(label, tags...); err != nil {
+		return err
+	}
+	return printTags(label, "untagged", output)
+}
+
+func validateTags(tags []string) error {
+	if len(tags) == 0 {
+		return Usagef("at least one tag is required")
+	}
+	for _, tag := range tags {
+		if !tagPattern.MatchString(tag) {
+			return Usagef("invalid tag %q: use letters, digits, '-', '_' or '.'", tag)
+		}
+	}
+	return nil
+}
+
+func printTags(label, action string, output OutputFormat) error {
+	location, err := storage.GetLocation(label)
+	if err != nil {
+		return err
+	}
+
+	if output.IsJSON() {
+		return ui.PrintJSON(ui.LocationDocument{SchemaVersion: ui.SchemaVersion, Action: action, Location: *location}, output == OutputNDJSON)
+	}
+
+	fmt.Printf("Tags of %s: %s\n", label, formatTags(location.Tags))
+	return nil
+}
+
+func formatTags(tags []string) string {
+	if len(tags) == 0 {
+		return "(none)"
+	}
+	return strings.Join(tags, ", ")
+}
+
+// CompleteTags returns every tag in use, for shell completion
+func CompleteTags() []string {
+	locations, _, err := storage.ListLocations()
+	if err != nil {
+		return nil
+	}
+	var tags []string
+	for _, loc := range locations {
+		for _, tag := range loc.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	return tags
+}
+
+// locationWeather is the outcome of fetching one location of a group
+type locationWeather struct {
+	Location models.Location
+	Weather  *models.WeatherResponse
+	Err      error
+}
+
+// maxConcurrentFetches bounds the requests in flight for the multi-location
+// views when the provider cannot batch them, to stay polite to the API
+const maxConcurrentFetches = 4
+
+// fetchAll fetches the weather of every location. Providers that support
+// batches get one batch per timezone, and a batch that fails is retried one
+// location at a time so that only the locations that really fail report an
+// error. Otherwise the locations are fetched one by one. Requests run
+// concurrently, at most maxConcurrentFetches at a time. The results are in the
+// same order as locations.
+func fetchAll(provider api.WeatherProvider, locations []models.Location, forecast api.ForecastOptions) []locationWeather {
+	results := make([]locationWeather, len(locations))
+	for i, loc := range locations {
+		results[i].Location = loc
+	}
+
+	sem := make(chan struct{}, maxConcurrentFetches)
+	var wg sync.WaitGroup
+
+	// fetchEach fetches the locations at indexes with one request each
+	fetchEach := func(indexes []int) {
+		for _, i := range indexes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				loc := locations[i]
+				opts := forecast
+				opts.Timezone = loc.Timezone
+				results[i].Weather, results[i].Err = provider.GetWeather(loc.Lat, loc.Lon, opts)
+			}()
+		}
+	}
+
+	batch, ok := provider.(api.BatchWeatherProvider)
+	if !ok {
+		indexes := make([]int, len(locations))
+		for i := range indexes {
+			indexes[i] = i
+		}
+		fetchEach(indexes)
+		wg.Wait()
+		return results
+	}
+
+	// The timezone is a request parameter, so it splits the batches
+	byTimezone := map[string][]int{}
+	var timezones []string
+	for i, loc := range locations {
+		if _, seen := byTimezone[loc.Timezone]; !seen {
+			timezones = append(timezones, loc.Timezone)
+		}
+		byTimezone[loc.Timezone] = append(byTimezone[loc.Timezone], i)
+	}
+
+	for _, tz := range timezones {
+		indexes := byTimezone[tz]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			coords := make([]api.Coordinate, len(indexes))
+			for j, i := range indexes {
+				coords[j] = api.Coordinate{Lat: locations[i].Lat, Lon: locations[i].Lon}
+			}
+			opts := forecast
+			opts.Timezone = tz
+
+			sem <- struct{}{}
+			weathers, err := batch.GetWeatherBatch(coords, opts)
+			<-sem
+
+			switch {
+			case err == nil:
+				for j, i := range indexes {
+					results[i].Weather = weathers[j]
+				}
+			case len(indexes) == 1:
+				results[indexes[0]].Err = err
+			default:
+				// One bad location fails the whole batch; find out which
+				fetchEach(indexes)
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// GroupWeatherCommand shows the weather of every location tagged with group,
+// in the saved order. Locations that fail are reported without hiding the
+// others.
+func GroupWeatherCommand(provider api.WeatherProvider, group string, opts WeatherOptions) error {
+	locations, err := storage.LocationsByTag(group)
+	if err != nil {
+		return err
+	}
+
+	opts, units, err := resolveWeatherOptions(opts)
+	if err != nil {
+		return err
+	}
+
+	results := fetchAll(provider, locations, forecastOptions(opts, units))
+
+	var failed []error
+	doc := ui.GroupDocument{SchemaVersion: ui.SchemaVersion, Group: group, Locations: []ui.WeatherDocument{}}
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result.Err)
+			switch opts.Output {
+			case OutputJSON:
+				doc.Errors = append(doc.Errors, ui.LocationError{Label: result.Location.Label, Error: result.Err.Error()})
+			case OutputNDJSON:
+				// One error line per failed location, next to the documents
+				// of the others on stdout
+				errDoc := errorDocument(result.Err)
+				errDoc.Label = result.Location.Label
+				ui.WriteJSON(os.Stderr, errDoc, true)
+			default:
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", result.Location.Label, result.Err)
+			}
+			continue
+		}
+
+		switch opts.Output {
+		case OutputJSON:
+			weatherDoc := ui.NewWeatherDocument(&result.Location, result.Weather, opts.dailyRows(), units)
+			if opts.Hourly {
+				weatherDoc.Hourly = ui.NewHourlyDocuments(result.Weather, opts.Hours)
+			}
+			if air := airQuality(provider, &result.Location, opts); air != nil {
+				weatherDoc.AirQuality = ui.NewAirQualityValues(air)
+			}
+			doc.Locations = append(doc.Locations, weatherDoc)
+		default:
+			if err := displayWeather(provider, &result.Location, result.Weather, units, opts); err != nil {
+				return err
+			}
+		}
+	}
+
+	if opts.Output == OutputJSON {
+		if err := ui.PrintJSON(doc, false); err != nil {
+			return err
+		}
+	}
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d locations in group '%s' failed: %w", len(failed), len(results), group, failed[0])
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/api/apitest"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// failLatitude95 answers forecast requests like the real API: any request
+// that includes latitude 95 fails as a whole, the others get one forecast per
+// location whose timezone names its latitude
+func failLatitude95(w http.ResponseWriter, r *http.Request) {
+	lats := strings.Split(r.URL.Query().Get("latitude"), ",")
+	if slices.Contains(lats, "95.0000") {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":true,"reason":"Latitude must be in range of -90 to 90°. Given: 95.0."}`)
+		return
+	}
+	var forecasts []models.WeatherResponse
+	for _, lat := range lats {
+		forecasts = append(forecasts, models.WeatherResponse{Timezone: "lat=" + lat})
+	}
+	if len(forecasts) == 1 {
+		json.NewEncoder(w).Encode(forecasts[0])
+		return
+	}
+	json.NewEncoder(w).Encode(forecasts)
+}
+
+func TestFetchAllFallsBackPerLocation(t *testing.T) {
+	s := apitest.NewServer()
+	defer s.Close()
+	s.Handle("/v1/forecast", failLatitude95)
+
+	locations := []models.Location{
+		{Label: "home", Lat: 41},
+		{Label: "bad", Lat: 95},
+		{Label: "paris", Lat: 48, Timezone: "Europe/Paris"},
+		{Label: "work", Lat: 39},
+	}
+	results := fetchAll(s.Client(api.WithRetries(0)), locations, api.ForecastOptions{})
+
+	for i, result := range results {
+		if result.Location.Label != locations[i].Label {
+			t.Fatalf("result %d is for %q, want %q", i, result.Location.Label, locations[i].Label)
+		}
+		if result.Location.Label == "bad" {
+			var apiErr *api.APIError
+			if !errors.As(result.Err, &apiErr) {
+				t.Errorf("bad: err = %v, want an APIError", result.Err)
+			}
+			continue
+		}
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Location.Label, result.Err)
+			continue
+		}
+		if want := fmt.Sprintf("lat=%.4f", locations[i].Lat); result.Weather.Timezone != want {
+			t.Errorf("%s: got the forecast for %s, want %s", result.Location.Label, result.Weather.Timezone, want)
+		}
+	}
+
+	// One failed batch of three, three single retries and the Paris batch
+	if got := len(s.Requests()); got != 5 {
+		t.Errorf("got %d requests, want 5", got)
+	}
+}
+
+func TestGroupWeatherCommandNDJSON(t *testing.T) {
+	useTempHome(t)
+	if err := storage.AddLocation("bad", "Nowhere", 95, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, label := range []string{"home", "bad"} {
+		if err := storage.TagLocation(label, "trip"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := apitest.NewServer()
+	defer s.Close()
+	s.Handle("/v1/forecast", failLatitude95)
+
+	var stdout string
+	stderr, err := captureStderr(t, func() error {
+		var err error
+		stdout, err = captureStdout(t, func() error {
+			return GroupWeatherCommand(s.Client(api.WithRetries(0)), "trip", WeatherOptions{Output: OutputNDJSON})
+		})
+		return err
+	})
+	if ExitCode(err) != ExitAPI {
+		t.Errorf("err = %v, want an API error", err)
+	}
+
+	var doc ui.WeatherDocument
+	if err := json.Unmarshal([]byte(stdout), &doc); err != nil || doc.Location.Label != "home" {
+		t.Errorf("stdout is not the document of home: %v\n%s", err, stdout)
+	}
+	var errDoc ui.ErrorDocument
+	if err := json.Unmarshal([]byte(stderr), &errDoc); err != nil {
+		t.Fatalf("stderr is not a JSON error: %v\n%s", err, stderr)
+	}
+	if errDoc.Label != "bad" || errDoc.Code != "api" || errDoc.Status != http.StatusBadRequest {
+		t.Errorf("got error %+v", errDoc)
+	}
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// MaxHistoryDays bounds the date range of one history lookup
+const MaxHistoryDays = 366
+
+// HistoryOptions selects the days shown by HistoryCommand
+type HistoryOptions struct {
+	From   string // first day, YYYY-MM-DD
+	To     string // last day, YYYY-MM-DD; empty means From
+	Units  string // units specification from --units; empty uses the preference
+	Output OutputFormat
+	City   CityOptions
+}
+
+// HistoryCommand shows the daily weather of a past date range for a saved
+// label, a city name or, when target is empty, the default location
+func HistoryCommand(provider api.Provider, target string, opts HistoryOptions) error {
+	historical, ok := provider.(api.HistoricalProvider)
+	if !ok {
+		return fmt.Errorf("historical weather is not supported by this provider")
+	}
+
+	from, to, err := parseDateRange(opts.From, opts.To)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return err
+	}
+	units, err := resolveUnits(opts.Units, prefs)
+	if err != nil {
+		return err
+	}
+
+	location, err := lookupLocation(provider, target, opts.City)
+	if err != nil {
+		return err
+	}
+
+	history, err := historical.GetHistorical(location.Lat, location.Lon, api.HistoricalOptions{
+		From:     from,
+		To:       to,
+		Units:    units,
+		Timezone: location.Timezone,
+	})
+	if err != nil {
+		return err
+	}
+
+	if opts.Output.IsJSON() {
+		doc := ui.NewHistoryDocument(location, history, from.Format(time.DateOnly), to.Format(time.DateOnly), units)
+		return ui.PrintJSON(doc, opts.Output == OutputNDJSON)
+	}
+	ui.DisplayHistory(location, history, units)
+	return nil
+}
+
+// parseDateRange validates --from and --to: a range of past days covered by
+// the archive, at most MaxHistoryDays long
+func parseDateRange(fromFlag, toFlag string) (time.Time, time.Time, error) {
+	if fromFlag == "" {
+		return time.Time{}, time.Time{}, Usagef("--from is required")
+	}
+	if toFlag == "" {
+		toFlag = fromFlag
+	}
+
+	from, err := time.Parse(time.DateOnly, fromFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, Usagef("invalid --from date %q (use YYYY-MM-DD)", fromFlag)
+	}
+	to, err := time.Parse(time.DateOnly, toFlag)
+	if err != nil {
+		return time.Time{}, time.Time{}, Usagef("invalid --to date %q (use YYYY-MM-DD)", toFlag)
+	}
+
+	today, _ := time.Parse(time.DateOnly, time.Now().Format(time.DateOnly))
+	switch {
+	case to.Before(from):
+		return time.Time{}, time.Time{}, Usagef("--to %s is before --from %s", toFlag, fromFlag)
+	case from.Before(api.FirstArchiveDate):
+		return time.Time{}, time.Time{}, Usagef("the archive starts on %s", api.FirstArchiveDate.Format(time.DateOnly))
+	case !to.Before(today):
+		return time.Time{}, time.Time{}, Usagef("--to must be before today; use 'uweather --past N' for recent days")
+	case to.Sub(from) >= MaxHistoryDays*24*time.Hour:
+		return time.Time{}, time.Time{}, Usagef("at most %d days can be looked up at once", MaxHistoryDays)
+	}
+	return from, to, nil
+}
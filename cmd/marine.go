@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// MarineOptions selects the units and output of MarineCommand
+type MarineOptions struct {
+	Units  string // units specification from --units; empty uses the preference
+	Output OutputFormat
+	City   CityOptions
+}
+
+// MarineCommand shows the current waves and swell of a saved label, a city
+// name or, when target is empty, the default location. Inland locations fail
+// with api.ErrNoMarineData.
+func MarineCommand(provider api.Provider, target string, opts MarineOptions) error {
+	mp, ok := provider.(api.MarineProvider)
+	if !ok {
+		return fmt.Errorf("marine forecasts are not supported by this provider")
+	}
+
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return err
+	}
+	units, err := resolveUnits(opts.Units, prefs)
+	if err != nil {
+		return err
+	}
+
+	location, err := lookupLocation(provider, target, opts.City)
+	if err != nil {
+		return err
+	}
+
+	marine, err := mp.GetMarine(location.Lat, location.Lon, units)
+	if err != nil {
+		return err
+	}
+
+	if opts.Output.IsJSON() {
+		return ui.PrintJSON(ui.NewMarineDocument(location, marine, units), opts.Output == OutputNDJSON)
+	}
+	ui.DisplayMarine(location, marine, units)
+	return nil
+}
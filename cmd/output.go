@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// OutputFormat selects how commands print their results
+type OutputFormat string
+
+const (
+	// OutputText is the human readable box drawing output
+	OutputText OutputFormat = "text"
+	// OutputJSON prints one indented JSON document
+	OutputJSON OutputFormat = "json"
+	// OutputNDJSON prints one compact JSON document per line, one per item
+	OutputNDJSON OutputFormat = "ndjson"
+)
+
+// ParseOutputFormat validates the value of --output
+func ParseOutputFormat(s string) (OutputFormat, error) {
+	switch OutputFormat(s) {
+	case "", OutputText:
+		return OutputText, nil
+	case OutputJSON, OutputNDJSON:
+		return OutputFormat(s), nil
+	}
+	return OutputText, Usagef("unknown output format %q (use text, json or ndjson)", s)
+}
+
+// IsJSON reports whether the format is one of the JSON modes
+func (f OutputFormat) IsJSON() bool {
+	return f == OutputJSON || f == OutputNDJSON
+}
+
+// Exit codes returned by main
+const (
+	ExitOK        = 0
+	ExitError     = 1 // unclassified failure
+	ExitUsage     = 2 // bad command line
+	ExitNotFound  = 3 // unknown label or no default location
+	ExitConflict  = 4 // label already exists
+	ExitAmbiguous = 5 // city name matches several places
+	ExitNetwork   = 6 // API unreachable or no answer in time
+	ExitRateLimit = 7 // API rate limit hit
+	ExitAPI       = 8 // API answered with an error
+)
+
+// UsageError reports a malformed command line
+type UsageError struct {
+	Msg string
+}
+
+func (e *UsageError) Error() string {
+	return e.Msg
+}
+
+// Usagef builds a UsageError
+func Usagef(format string, args ...any) error {
+	return &UsageError{Msg: fmt.Sprintf(format, args...)}
+}
+
+// ExitCode maps an error to the process exit code
+func ExitCode(err error) int {
+	var usageErr *UsageError
+	var ambiguousErr *AmbiguousCityError
+	var apiErr *api.APIError
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usageErr):
+		return ExitUsage
+	case errors.As(err, &ambiguousErr):
+		return ExitAmbiguous
+	case errors.Is(err, storage.ErrLocationNotFound), errors.Is(err, storage.ErrNoDefault),
+		errors.Is(err, api.ErrCityNotFound), errors.Is(err, api.ErrNoMarineData):
+		return ExitNotFound
+	case errors.Is(err, storage.ErrLocationExists):
+		return ExitConflict
+	case errors.Is(err, api.ErrNetwork):
+		return ExitNetwork
+	case errors.Is(err, api.ErrRateLimited):
+		return ExitRateLimit
+	case errors.As(err, &apiErr):
+		return ExitAPI
+	}
+	return ExitError
+}
+
+// errorCode is the machine readable name of an exit code
+func errorCode(exitCode int) string {
+	switch exitCode {
+	case ExitUsage:
+		return "usage"
+	case ExitNotFound:
+		return "not_found"
+	case ExitConflict:
+		return "conflict"
+	case ExitAmbiguous:
+		return "ambiguous"
+	case ExitNetwork:
+		return "network"
+	case ExitRateLimit:
+		return "rate_limited"
+	case ExitAPI:
+		return "api"
+	}
+	return "error"
+}
+
+// ReportError writes err to w in the given format and returns the exit code
+func ReportError(w io.Writer, err error, format OutputFormat) int {
+	code := ExitCode(err)
+	if format.IsJSON() {
+		ui.WriteJSON(w, errorDocument(err), true)
+		return code
+	}
+	fmt.Fprintf(w, "Error: %v\n", err)
+	return code
+}
+
+// errorDocument is the JSON form of err
+func errorDocument(err error) ui.ErrorDocument {
+	code := ExitCode(err)
+	doc := ui.ErrorDocument{
+		SchemaVersion: ui.SchemaVersion,
+		Error:         err.Error(),
+		Code:          errorCode(code),
+		ExitCode:      code,
+	}
+	var ambiguousErr *AmbiguousCityError
+	if errors.As(err, &ambiguousErr) {
+		doc.Candidates = ambiguousErr.Candidates
+	}
+	var apiErr *api.APIError
+	if errors.As(err, &apiErr) {
+		doc.Status, doc.Reason = apiErr.Status, apiErr.Reason
+	}
+	return doc
+}
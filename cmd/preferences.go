@@ -0,0 +1,309 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+	"github.com/ugur-claw/uweather/storage"
+	"github.com/ugur-claw/uweather/ui"
+)
+
+// preference describes one key of the preferences section
+type preference struct {
+	Key         string
+	Description string
+	Default     string
+	// Validate checks a value and returns its canonical spelling
+	Validate func(value string) (string, error)
+	Get      func(prefs *models.Preferences) string
+	Set      func(prefs *models.Preferences, value string)
+}
+
+var languagePattern = regexp.MustCompile(`^[a-z]{2}$`)
+
+// preferences lists every key accepted by 'uweather config'
+var preferences = []preference{
+	{
+		Key:         "units",
+		Description: "metric, imperial, or a list such as celsius,mph",
+		Default:     "metric",
+		Validate: func(v string) (string, error) {
+			units, err := models.ParseUnits(v)
+			return units.String(), err
+		},
+		Get: func(p *models.Preferences) string { return p.Units },
+		Set: func(p *models.Preferences, v string) { p.Units = v },
+	},
+	{
+		Key:         "language",
+		Description: "two-letter language code for place names, e.g. en or tr",
+		Default:     "en",
+		Validate: func(v string) (string, error) {
+			v = strings.ToLower(v)
+			if !languagePattern.MatchString(v) {
+				return "", fmt.Errorf("language must be a two-letter code such as en, got %q", v)
+			}
+			return v, nil
+		},
+		Get: func(p *models.Preferences) string { return p.Language },
+		Set: func(p *models.Preferences, v string) { p.Language = v },
+	},
+	{
+		Key:         "date_format",
+		Description: "short (Mon Jan 2), iso (2006-01-02), eu (02.01.2006) or us (01/02/2006)",
+		Default:     ui.DateShort,
+		Validate:    oneOf(ui.DateFormats...),
+		Get:         func(p *models.Preferences) string { return p.DateFormat },
+		Set:         func(p *models.Preferences, v string) { p.DateFormat = v },
+	},
+	{
+		Key:         "time_format",
+		Description: "24h or 12h",
+		Default:     ui.Time24h,
+		Validate:    oneOf(ui.TimeFormats...),
+		Get:         func(p *models.Preferences) string { return p.TimeFormat },
+		Set:         func(p *models.Preferences, v string) { p.TimeFormat = v },
+	},
+	{
+		Key:         "theme",
+		Description: "auto (color on terminals), dark, light or none",
+		Default:     ui.ThemeAuto,
+		Validate:    oneOf(ui.Themes...),
+		Get:         func(p *models.Preferences) string { return p.Theme },
+		Set:         func(p *models.Preferences, v string) { p.Theme = v },
+	},
+	{
+		Key:         "days",
+		Description: "default number of forecast days (1-16)",
+		Default:     "1",
+		Validate: func(v string) (string, error) {
+			days, err := strconv.Atoi(v)
+			if err != nil || days < 1 || days > api.MaxForecastDays {
+				return "", fmt.Errorf("days must be a number from 1 to %d, got %q", api.MaxForecastDays, v)
+			}
+			return strconv.Itoa(days), nil
+		},
+		Get: func(p *models.Preferences) string {
+			if p.Days == 0 {
+				return ""
+			}
+			return strconv.Itoa(p.Days)
+		},
+		Set: func(p *models.Preferences, v string) { p.Days, _ = strconv.Atoi(v) },
+	},
+	{
+		Key:         "output",
+		Description: "default output format: text, json or ndjson",
+		Default:     string(OutputText),
+		Validate: func(v string) (string, error) {
+			format, err := ParseOutputFormat(v)
+			return string(format), err
+		},
+		Get: func(p *models.Preferences) string { return p.Output },
+		Set: func(p *models.Preferences, v string) { p.Output = v },
+	},
+	{
+		Key:         "cache_ttl",
+		Description: "how long forecasts stay fresh, e.g. 10m or 1h",
+		Default:     "10m",
+		Validate: func(v string) (string, error) {
+			ttl, err := time.ParseDuration(v)
+			if err != nil || ttl <= 0 {
+				return "", fmt.Errorf("cache_ttl must be a positive duration such as 10m, got %q", v)
+			}
+			return ttl.String(), nil
+		},
+		Get: func(p *models.Preferences) string { return p.CacheTTL },
+		Set: func(p *models.Preferences, v string) { p.CacheTTL = v },
+	},
+	{
+		Key:         "show_aqi",
+		Description: "on adds the air quality to the current weather",
+		Default:     "off",
+		Validate:    oneOf("on", "off"),
+		Get:         func(p *models.Preferences) string { return p.ShowAQI },
+		Set:         func(p *models.Preferences, v string) { p.ShowAQI = v },
+	},
+}
+
+// oneOf builds a validator accepting a fixed set of values
+func oneOf(values ...string) func(string) (string, error) {
+	return func(v string) (string, error) {
+		v = strings.ToLower(v)
+		if !slices.Contains(values, v) {
+			return "", fmt.Errorf("must be one of %s, got %q", strings.Join(values, ", "), v)
+		}
+		return v, nil
+	}
+}
+
+// findPreference looks up a key, suggesting the list of keys when unknown
+func findPreference(key string) (*preference, error) {
+	for i := range preferences {
+		if preferences[i].Key == key {
+			return &preferences[i], nil
+		}
+	}
+	keys := make([]string, len(preferences))
+	for i, p := range preferences {
+		keys[i] = p.Key
+	}
+	return nil, Usagef("unknown config key %q (valid keys: %s)", key, strings.Join(keys, ", "))
+}
+
+// CompletePreferenceKeys returns the config keys for shell completion
+func CompletePreferenceKeys(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	keys := make([]string, len(preferences))
+	for i, p := range preferences {
+		keys[i] = p.Key
+	}
+	return keys
+}
+
+// ConfigGetCommand prints the value of one preference
+func ConfigGetCommand(key string) error {
+	pref, err := findPreference(key)
+	if err != nil {
+		return err
+	}
+
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	value := pref.Get(&prefs)
+	if value == "" {
+		value = pref.Default
+	}
+	fmt.Println(value)
+	return nil
+}
+
+// ConfigSetCommand validates and stores one preference
+func ConfigSetCommand(key, value string) error {
+	pref, err := findPreference(key)
+	if err != nil {
+		return err
+	}
+
+	canonical, err := pref.Validate(strings.TrimSpace(value))
+	if err != nil {
+		return Usagef("invalid value for %s: %v", key, err)
+	}
+
+	err = storage.UpdatePreferences(func(prefs *models.Preferences) error {
+		pref.Set(prefs, canonical)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s = %s\n", key, canonical)
+	return nil
+}
+
+// ConfigUnsetCommand restores the default of one preference
+func ConfigUnsetCommand(key string) error {
+	pref, err := findPreference(key)
+	if err != nil {
+		return err
+	}
+
+	err = storage.UpdatePreferences(func(prefs *models.Preferences) error {
+		pref.Set(prefs, "")
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%s reset to default (%s)\n", key, pref.Default)
+	return nil
+}
+
+// ConfigListCommand prints every preference with its value
+func ConfigListCommand() error {
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	for _, pref := range preferences {
+		value := pref.Get(&prefs)
+		if value == "" {
+			value = pref.Default + " (default)"
+		}
+		fmt.Printf("%-12s %-20s %s\n", pref.Key, value, pref.Description)
+	}
+	return nil
+}
+
+// ApplyPreferences configures the renderers from the stored preferences
+func ApplyPreferences() error {
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return err
+	}
+
+	ui.Configure(ui.Settings{
+		DateFormat: prefs.DateFormat,
+		TimeFormat: prefs.TimeFormat,
+		Theme:      prefs.Theme,
+	})
+	return nil
+}
+
+// ResolveOutputFormat parses --output, falling back to the "output"
+// preference when the flag is not given
+func ResolveOutputFormat(flag string) (OutputFormat, error) {
+	if flag != "" {
+		return ParseOutputFormat(flag)
+	}
+
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return OutputText, nil
+	}
+	return ParseOutputFormat(prefs.Output)
+}
+
+// resolveWeatherOptions fills in the options left unset on the command line
+// from the preferences and parses the units
+func resolveWeatherOptions(opts WeatherOptions) (WeatherOptions, models.Units, error) {
+	prefs, err := storage.GetPreferences()
+	if err != nil {
+		return opts, models.Units{}, err
+	}
+
+	if opts.Days == 0 {
+		opts.Days = max(prefs.Days, 1)
+	}
+	opts.AQI = opts.AQI || prefs.ShowAQI == "on"
+
+	units, err := resolveUnits(opts.Units, prefs)
+	return opts, units, err
+}
+
+// resolveUnits parses the --units flag, falling back to the stored preference
+func resolveUnits(flag string, prefs models.Preferences) (models.Units, error) {
+	if flag != "" {
+		return models.ParseUnits(flag)
+	}
+
+	units, err := models.ParseUnits(prefs.Units)
+	if err != nil {
+		return models.Units{}, fmt.Errorf("invalid units preference: %w", err)
+	}
+	return units, nil
+}
@@ -1,196 +1,707 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/ugur-claw/uweather/api"
 	"github.com/ugur-claw/uweather/cmd"
 	"github.com/ugur-claw/uweather/storage"
 )
 
+// output is the format selected with --output, used for error reporting too
+var output = cmd.OutputText
+
+// Global flags, accepted by every command
+var (
+	outputFlag    string
+	configDirFlag string
+	noCacheFlag   bool
+	refreshFlag   bool
+	timeoutFlag   time.Duration
+)
+
+// Command flags
+var (
+	daysFlag    int
+	hourlyFlag  bool
+	hoursFlag   int
+	pastFlag    int
+	detailsFlag bool
+	aqiFlag     bool
+	unitsFlag   string
+	labelFlag   string
+	pickFlag    int
+	countryFlag string
+	latFlag     float64
+	lonFlag     float64
+	dryRunFlag  bool
+
+	cityFlag     string
+	timezoneFlag string
+	beforeFlag   string
+	afterFlag    string
+	groupFlag    string
+	fromFlag     string
+	toFlag       string
+)
+
+// client is created on first use by the commands that need the network
+var client api.Provider
+
 func main() {
-	// Set up custom flag parsing
-	// We'll handle flags manually after detecting subcommand
+	app := newApp()
+	cmd.SetReservedLabels(app.CommandNames())
+	err := app.Run(os.Args[1:])
+	if client != nil {
+		cmd.Flush(client)
+	}
+	if err != nil {
+		if cmd.ExitCode(err) == cmd.ExitUsage {
+			// The command line may have failed to parse before setup ran;
+			// the error is still reported in the requested format
+			app.ParseGlobalFlags(os.Args[1:])
+			resolveOutput()
+		}
+		fail(err)
+	}
+}
 
-	// Ensure config directory exists
-	if err := cmd.EnsureConfigDirCommand(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+func newApp() *cmd.App {
+	app := &cmd.App{
+		Name:    "uweather",
+		Summary: "Weather CLI Tool",
+		Flags:   globalFlags,
+		Before:  setup,
+		Footer:  footer,
+		FlagValues: map[string]func() []string{
+			"days":   func() []string { return numbers(1, api.MaxForecastDays) },
+			"output": func() []string { return []string{"text", "json", "ndjson"} },
+			"units":  func() []string { return []string{"metric", "imperial"} },
+			"group":  completeTags,
+			"before": func() []string { return completeLabels(nil) },
+			"after":  func() []string { return completeLabels(nil) },
+		},
 	}
 
-	// Get all args
-	args := os.Args[1:]
-	if len(args) == 0 {
+	weather := &cmd.Command{
+		Name:    "weather",
+		Args:    "[label|city|lat,lon]",
+		Summary: "Show weather for the default location, a saved label, a city or coordinates",
+		MaxArgs: -1,
+		Flags: func(fs *flag.FlagSet) {
+			weatherFlags(fs)
+			coordinateFlags(fs)
+			cityFlags(fs)
+			fs.StringVar(&groupFlag, "group", "", "show every location tagged with `tag`")
+		},
+		Run:      runWeather,
+		Complete: firstLabel,
+	}
+
+	app.Default = weather
+	app.Commands = []*cmd.Command{
+		weather,
+		{
+			Name:    "add",
+			Args:    "[city] --label name",
+			Summary: "Add a location by city name, or by coordinates with --lat and --lon",
+			MaxArgs: -1,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&labelFlag, "label", "", "`name` of the new location (required)")
+				coordinateFlags(fs)
+				cityFlags(fs)
+			},
+			Run: runAdd,
+		},
+		{
+			Name:     "remove",
+			Aliases:  []string{"rm"},
+			Args:     "[label]",
+			Summary:  "Remove a saved location",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: completeLabels,
+			Run: func(ctx *cmd.Context) error {
+				return cmd.RemoveCommand(ctx.Args[0], output)
+			},
+		},
+		{
+			Name:     "rename",
+			Args:     "[old] [new]",
+			Summary:  "Change the label of a saved location",
+			MinArgs:  2,
+			MaxArgs:  2,
+			Complete: firstLabel,
+			Run: func(ctx *cmd.Context) error {
+				return cmd.RenameCommand(ctx.Args[0], ctx.Args[1], output)
+			},
+		},
+		{
+			Name:     "edit",
+			Args:     "[label]",
+			Summary:  "Change the city name, coordinates, country or timezone of a saved location",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: completeLabels,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&cityFlag, "city", "", "new display `name` of the city")
+				coordinateFlags(fs)
+				fs.StringVar(&countryFlag, "country", "", "new `country` name")
+				fs.StringVar(&timezoneFlag, "timezone", "", "IANA `zone` for local times, or auto")
+			},
+			Run: runEdit,
+		},
+		{
+			Name:     "move",
+			Args:     "[label] --before|--after [label]",
+			Summary:  "Reorder saved locations",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: completeLabels,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&beforeFlag, "before", "", "move in front of this `label`")
+				fs.StringVar(&afterFlag, "after", "", "move behind this `label`")
+			},
+			Run: func(ctx *cmd.Context) error {
+				if (beforeFlag == "") == (afterFlag == "") {
+					return cmd.Usagef("give exactly one of --before or --after")
+				}
+				if afterFlag != "" {
+					return cmd.MoveCommand(ctx.Args[0], afterFlag, true, output)
+				}
+				return cmd.MoveCommand(ctx.Args[0], beforeFlag, false, output)
+			},
+		},
+		{
+			Name:    "all",
+			Aliases: []string{"dashboard"},
+			Summary: "Show the current weather of every saved location in one table",
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&unitsFlag, "units", "", "`units` for this run: metric, imperial, or e.g. celsius,mph")
+				fs.StringVar(&groupFlag, "group", "", "only show locations tagged with `tag`")
+			},
+			Run: func(ctx *cmd.Context) error {
+				provider, err := newProvider()
+				if err != nil {
+					return err
+				}
+				return cmd.DashboardCommand(provider, groupFlag, cmd.WeatherOptions{Units: unitsFlag, Output: output})
+			},
+		},
+		{
+			Name:    "history",
+			Args:    "[label|city] --from date [--to date]",
+			Summary: "Show the daily weather of past days from the archive",
+			MaxArgs: -1,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&fromFlag, "from", "", "first `date` to show, as YYYY-MM-DD (required)")
+				fs.StringVar(&toFlag, "to", "", "last `date` to show, as YYYY-MM-DD (default: --from)")
+				fs.StringVar(&unitsFlag, "units", "", "`units` for this run: metric, imperial, or e.g. celsius,mph")
+				cityFlags(fs)
+			},
+			Complete: firstLabel,
+			Run:      runHistory,
+		},
+		{
+			Name:     "air",
+			Args:     "[label|city]",
+			Summary:  "Show the air quality and pollen counts",
+			MaxArgs:  -1,
+			Flags:    cityFlags,
+			Complete: firstLabel,
+			Run: func(ctx *cmd.Context) error {
+				provider, err := newProvider()
+				if err != nil {
+					return err
+				}
+				return cmd.AirCommand(provider, strings.Join(ctx.Args, " "), cmd.CityOptions{Pick: pickFlag, Country: countryFlag}, output)
+			},
+		},
+		{
+			Name:    "marine",
+			Args:    "[label|city]",
+			Summary: "Show the waves, swell and sea temperature at the coast",
+			MaxArgs: -1,
+			Flags: func(fs *flag.FlagSet) {
+				fs.StringVar(&unitsFlag, "units", "", "`units` for this run: metric, imperial, or e.g. celsius,ft; wave heights follow the length unit, m or ft")
+				cityFlags(fs)
+			},
+			Complete: firstLabel,
+			Run:      runMarine,
+		},
+		{
+			Name:    "tag",
+			Summary: "Group saved locations with tags",
+			Commands: []*cmd.Command{
+				{
+					Name:     "add",
+					Args:     "[label] [tag...]",
+					Summary:  "Add tags to a saved location",
+					MinArgs:  2,
+					MaxArgs:  -1,
+					Complete: completeLabelThenTags,
+					Run: func(ctx *cmd.Context) error {
+						return cmd.TagAddCommand(ctx.Args[0], ctx.Args[1:], output)
+					},
+				},
+				{
+					Name:     "rm",
+					Aliases:  []string{"remove"},
+					Args:     "[label] [tag...]",
+					Summary:  "Remove tags from a saved location",
+					MinArgs:  2,
+					MaxArgs:  -1,
+					Complete: completeLabelThenTags,
+					Run: func(ctx *cmd.Context) error {
+						return cmd.TagRemoveCommand(ctx.Args[0], ctx.Args[1:], output)
+					},
+				},
+			},
+		},
+		{
+			Name:    "locations",
+			Aliases: []string{"list", "ls"},
+			Summary: "List all saved locations",
+			Run: func(ctx *cmd.Context) error {
+				return cmd.ListCommand(output)
+			},
+		},
+		{
+			Name:     "default",
+			Args:     "[label]",
+			Summary:  "Set the default location",
+			MinArgs:  1,
+			MaxArgs:  1,
+			Complete: completeLabels,
+			Run: func(ctx *cmd.Context) error {
+				return cmd.DefaultCommand(ctx.Args[0])
+			},
+		},
+		{
+			Name:    "units",
+			Args:    "[units]",
+			Summary: "Show or set the preferred units (metric, imperial, or e.g. celsius,mph)",
+			MaxArgs: 1,
+			Run: func(ctx *cmd.Context) error {
+				spec := ""
+				if len(ctx.Args) == 1 {
+					spec = ctx.Args[0]
+				}
+				return cmd.UnitsCommand(spec)
+			},
+		},
+		{
+			Name:    "config",
+			Summary: "Show or change preferences and manage the config file",
+			Commands: []*cmd.Command{
+				{
+					Name:    "list",
+					Summary: "Show all preferences",
+					Run: func(ctx *cmd.Context) error {
+						return cmd.ConfigListCommand()
+					},
+				},
+				{
+					Name:     "get",
+					Complete: cmd.CompletePreferenceKeys,
+					Args:     "[key]",
+					Summary:  "Show one preference",
+					MinArgs:  1,
+					MaxArgs:  1,
+					Run: func(ctx *cmd.Context) error {
+						return cmd.ConfigGetCommand(ctx.Args[0])
+					},
+				},
+				{
+					Name:     "set",
+					Complete: cmd.CompletePreferenceKeys,
+					Args:     "[key] [value]",
+					Summary:  "Change a preference",
+					MinArgs:  2,
+					MaxArgs:  2,
+					Run: func(ctx *cmd.Context) error {
+						return cmd.ConfigSetCommand(ctx.Args[0], ctx.Args[1])
+					},
+				},
+				{
+					Name:     "unset",
+					Complete: cmd.CompletePreferenceKeys,
+					Args:     "[key]",
+					Summary:  "Restore the default of a preference",
+					MinArgs:  1,
+					MaxArgs:  1,
+					Run: func(ctx *cmd.Context) error {
+						return cmd.ConfigUnsetCommand(ctx.Args[0])
+					},
+				},
+				{
+					Name:    "migrate",
+					Summary: "Upgrade the config file format",
+					Flags: func(fs *flag.FlagSet) {
+						fs.BoolVar(&dryRunFlag, "dry-run", false, "show the changes without writing them")
+					},
+					Run: func(ctx *cmd.Context) error {
+						return cmd.ConfigMigrateCommand(dryRunFlag)
+					},
+				},
+			},
+		},
+		{
+			Name:    "completion",
+			Args:    "[bash|zsh|fish]",
+			Summary: "Print a shell completion script",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Complete: func(args []string) []string {
+				return cmd.Shells
+			},
+			Run: func(ctx *cmd.Context) error {
+				return cmd.CompletionCommand(app.Name, ctx.Args[0])
+			},
+		},
+		{
+			Name:    "help",
+			Args:    "[command]",
+			Summary: "Show help for a command",
+			MaxArgs: -1,
+			Complete: func(args []string) []string {
+				path, err := app.Lookup(args)
+				if err != nil {
+					return nil
+				}
+				commands := app.Commands
+				if len(path) > 0 {
+					commands = path[len(path)-1].Commands
+				}
+				var names []string
+				for _, c := range commands {
+					names = append(names, c.Name)
+				}
+				return names
+			},
+			Run: func(ctx *cmd.Context) error {
+				path, err := app.Lookup(ctx.Args)
+				if err != nil {
+					return err
+				}
+				app.PrintHelp(path)
+				return nil
+			},
+		},
+	}
+	return app
+}
+
+func globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&outputFlag, "output", "", "output `format`: text, json or ndjson")
+	cmd.Alias(fs, "o", "output")
+	fs.StringVar(&configDirFlag, "config-dir", "", "keep config, cache and state in `dir` (or set UWEATHER_HOME)")
+	fs.BoolVar(&noCacheFlag, "no-cache", false, "bypass the response cache")
+	fs.BoolVar(&refreshFlag, "refresh", false, "fetch fresh data and update the cache")
+	fs.DurationVar(&timeoutFlag, "timeout", api.DefaultTimeout, "give up on an API call after `duration`, retries included (0 waits forever)")
+}
+
+func weatherFlags(fs *flag.FlagSet) {
+	fs.IntVar(&daysFlag, "days", 0, "show an `N`-day forecast (1-16, default 1 or the days preference)")
+	fs.IntVar(&pastFlag, "past", 0, "also show the past `N` days (up to 92)")
+	fs.BoolVar(&hourlyFlag, "hourly", false, "show an hour-by-hour forecast")
+	fs.IntVar(&hoursFlag, "hours", cmd.DefaultHours, "show `N` hours in the hourly forecast; implies --hourly")
+	fs.BoolVar(&detailsFlag, "details", false, "add gusts, dew point, pressure, clouds, UV index and visibility to the current conditions")
+	fs.BoolVar(&aqiFlag, "aqi", false, "add the air quality to the current conditions (or set show_aqi)")
+	fs.StringVar(&unitsFlag, "units", "", "`units` for this run: metric, imperial, or e.g. celsius,mph")
+}
+
+func coordinateFlags(fs *flag.FlagSet) {
+	fs.Float64Var(&latFlag, "lat", 0, "latitude of the location in `degrees` (with --lon)")
+	fs.Float64Var(&lonFlag, "lon", 0, "longitude of the location in `degrees` (with --lat)")
+}
+
+func cityFlags(fs *flag.FlagSet) {
+	fs.IntVar(&pickFlag, "pick", 0, "choose the `N`th match when a city name is ambiguous")
+	fs.StringVar(&countryFlag, "country", "", "only consider matches in this `country` (code or name)")
+}
+
+// setup applies the global flags before any command runs
+func setup(ctx *cmd.Context) error {
+	if err := resolveOutput(); err != nil {
+		return err
+	}
+
+	// Move a pre-XDG ~/.uweather install to the new locations
+	if legacy, err := storage.MigrateLegacyHome(); err != nil {
+		return err
+	} else if legacy != "" {
+		path, _ := storage.GetConfigPath()
+		fmt.Fprintf(os.Stderr, "Moved configuration from %s to %s\n", legacy, path)
+	}
+
+	if ctx.IsSet("lat") != ctx.IsSet("lon") {
+		return cmd.Usagef("--lat and --lon must be given together")
+	}
+	return cmd.ApplyPreferences()
+}
+
+// resolveOutput applies --config-dir and selects the output format from
+// --output or the output preference
+func resolveOutput() error {
+	storage.SetHome(configDirFlag)
+
+	format, err := cmd.ResolveOutputFormat(outputFlag)
+	if err != nil {
+		return err
+	}
+	output = format
+	return nil
+}
+
+func runWeather(ctx *cmd.Context) error {
+	if ctx.Bare() {
 		// No args - check if default location exists
 		_, defaultLabel, err := storage.ListLocations()
 		if err != nil || defaultLabel == "" {
-			// No default - show helpful message
 			printNoDefaultMessage()
-			return
-		}
-		// Show weather for default location
-		if err := cmd.WeatherCommand("", 1); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			return nil
 		}
-		return
-	}
-
-	// Parse common flags first
-	daysFlag := 1
-	labelFlag := ""
-	
-	// Look for common flags in the args
-	filteredArgs := []string{}
-	for i := 0; i < len(args); i++ {
-		arg := args[i]
-		if arg == "--days" || arg == "-days" {
-			if i+1 < len(args) {
-				fmt.Sscanf(args[i+1], "%d", &daysFlag)
-				i++
-			}
-		} else if arg == "--label" || arg == "-label" {
-			if i+1 < len(args) {
-				labelFlag = args[i+1]
-				i++
-			}
-		} else {
-			filteredArgs = append(filteredArgs, arg)
+	}
+
+	if ctx.IsSet("days") && (daysFlag < 1 || daysFlag > api.MaxForecastDays) {
+		return cmd.Usagef("--days must be between 1 and %d", api.MaxForecastDays)
+	}
+	if pastFlag < 0 || pastFlag > api.MaxPastDays {
+		return cmd.Usagef("--past must be between 0 and %d", api.MaxPastDays)
+	}
+	if hoursFlag < 1 {
+		return cmd.Usagef("--hours must be at least 1")
+	}
+
+	opts := cmd.WeatherOptions{
+		Days:    daysFlag,
+		Past:    pastFlag,
+		Units:   unitsFlag,
+		Output:  output,
+		Hourly:  hourlyFlag || ctx.IsSet("hours"),
+		Hours:   hoursFlag,
+		Details: detailsFlag,
+		AQI:     aqiFlag,
+		City:    cmd.CityOptions{Pick: pickFlag, Country: countryFlag},
+	}
+
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+
+	if groupFlag != "" {
+		if len(ctx.Args) > 0 || ctx.IsSet("lat") {
+			return cmd.Usagef("give either a location or --group, not both")
 		}
+		return cmd.GroupWeatherCommand(provider, groupFlag, opts)
 	}
 
-	args = filteredArgs
+	if ctx.IsSet("lat") {
+		if len(ctx.Args) > 0 {
+			return cmd.Usagef("give either a location or --lat/--lon, not both")
+		}
+		return withReverseGeocoder(func(reverse api.ReverseGeocoder) error {
+			return cmd.WeatherByCoordinatesCommand(provider, reverse, latFlag, lonFlag, opts)
+		})
+	}
 
-	// Determine the command based on first positional arg
-	if len(args) == 0 {
+	if len(ctx.Args) == 0 {
 		// Only flags - show weather for default location
-		if err := cmd.WeatherCommand("", daysFlag); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+		return cmd.WeatherCommand(provider, "", opts)
 	}
 
-	// First arg is the command
-	command := args[0]
+	arg := strings.Join(ctx.Args, " ")
 
-	switch command {
-	case "add":
-		// uweather add [city] --label [label]
-		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "Error: city name required. Usage: uweather add [city] --label [label]\n")
-			os.Exit(1)
-		}
-		city := args[1]
+	// Check if it's a saved label
+	if _, err := storage.GetLocation(arg); err == nil {
+		return cmd.WeatherCommand(provider, arg, opts)
+	}
+
+	// Not a saved label - try as coordinates
+	if lat, lon, ok := cmd.ParseCoordinates(arg); ok {
+		return withReverseGeocoder(func(reverse api.ReverseGeocoder) error {
+			return cmd.WeatherByCoordinatesCommand(provider, reverse, lat, lon, opts)
+		})
+	}
+
+	// Not coordinates either - try as city name
+	return cmd.WeatherByCityCommand(provider, arg, opts)
+}
+
+func runHistory(ctx *cmd.Context) error {
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	return cmd.HistoryCommand(provider, strings.Join(ctx.Args, " "), cmd.HistoryOptions{
+		From:   fromFlag,
+		To:     toFlag,
+		Units:  unitsFlag,
+		Output: output,
+		City:   cmd.CityOptions{Pick: pickFlag, Country: countryFlag},
+	})
+}
+
+func runMarine(ctx *cmd.Context) error {
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	return cmd.MarineCommand(provider, strings.Join(ctx.Args, " "), cmd.MarineOptions{
+		Units:  unitsFlag,
+		Output: output,
+		City:   cmd.CityOptions{Pick: pickFlag, Country: countryFlag},
+	})
+}
+
+func runAdd(ctx *cmd.Context) error {
+	name := strings.Join(ctx.Args, " ")
+
+	// uweather add [name] --lat [lat] --lon [lon] --label [label]
+	if ctx.IsSet("lat") {
 		if labelFlag == "" {
-			fmt.Fprintf(os.Stderr, "Error: --label is required when adding a city\n")
-			os.Exit(1)
-		}
-		if err := cmd.AddCommand(city, labelFlag); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			return cmd.Usagef("--label is required when adding a location")
 		}
-		return
+		return withReverseGeocoder(func(reverse api.ReverseGeocoder) error {
+			return cmd.AddCoordinatesCommand(reverse, name, labelFlag, latFlag, lonFlag, output)
+		})
+	}
 
-	case "remove":
-		// uweather remove [label]
-		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "Error: label required. Usage: uweather remove [label]\n")
-			os.Exit(1)
-		}
-		label := args[1]
-		if err := cmd.RemoveCommand(label); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+	// uweather add [city] --label [label]
+	if name == "" {
+		return cmd.Usagef("city name required. Usage: uweather add [city] --label [label]")
+	}
+	if labelFlag == "" {
+		return cmd.Usagef("--label is required when adding a city")
+	}
 
-	case "locations", "list", "ls":
-		// uweather locations
-		if err := cmd.ListCommand(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+	provider, err := newProvider()
+	if err != nil {
+		return err
+	}
+	return cmd.AddCommand(provider, name, labelFlag, cmd.CityOptions{Pick: pickFlag, Country: countryFlag}, output)
+}
 
-	case "default":
-		// uweather default [label]
-		if len(args) < 2 {
-			fmt.Fprintf(os.Stderr, "Error: label required. Usage: uweather default [label]\n")
-			os.Exit(1)
-		}
-		label := args[1]
-		if err := cmd.DefaultCommand(label); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		return
-
-	case "help", "--help", "-h":
-		printHelp()
-		return
-
-	default:
-		// Treat as city name or label
-		arg := args[0]
-
-		// Check if it's a saved label
-		locations, _, err := storage.ListLocations()
-		if err == nil {
-			isLabel := false
-			for _, loc := range locations {
-				if loc.Label == arg {
-					isLabel = true
-					break
-				}
-			}
+func runEdit(ctx *cmd.Context) error {
+	var changes cmd.LocationChanges
+	if ctx.IsSet("city") {
+		changes.City = &cityFlag
+	}
+	if ctx.IsSet("country") {
+		changes.Country = &countryFlag
+	}
+	if ctx.IsSet("timezone") {
+		changes.Timezone = &timezoneFlag
+	}
+	if ctx.IsSet("lat") {
+		changes.Lat, changes.Lon = &latFlag, &lonFlag
+	}
+	return cmd.EditCommand(ctx.Args[0], changes, output)
+}
 
-			if isLabel {
-				// It's a saved label - show weather for that location
-				if err := cmd.WeatherCommand(arg, daysFlag); err != nil {
-					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-					os.Exit(1)
-				}
-				return
-			}
-		}
+// firstLabel offers the saved labels for the first argument only
+func firstLabel(args []string) []string {
+	if len(args) > 0 {
+		return nil
+	}
+	return completeLabels(args)
+}
 
-		// Not a saved label - try as city name
-		if err := cmd.WeatherByCityCommand(arg, daysFlag); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
-		}
-		return
+// completeLabels offers the saved labels, honoring --config-dir
+func completeLabels(args []string) []string {
+	storage.SetHome(configDirFlag)
+	return cmd.CompleteLabels(args)
+}
+
+// completeTags offers the tags in use, honoring --config-dir
+func completeTags() []string {
+	storage.SetHome(configDirFlag)
+	return cmd.CompleteTags()
+}
+
+// completeLabelThenTags offers a label first and tags after it
+func completeLabelThenTags(args []string) []string {
+	if len(args) == 0 {
+		return completeLabels(args)
 	}
+	return completeTags()
 }
 
-func printHelp() {
-	fmt.Println(`uweather - Weather CLI Tool
+// numbers returns from..to as strings
+func numbers(from, to int) []string {
+	var values []string
+	for i := from; i <= to; i++ {
+		values = append(values, strconv.Itoa(i))
+	}
+	return values
+}
 
-Usage:
-  uweather                          Show weather for default location
-  uweather [label]                  Show weather for saved location
-  uweather [city]                   Show weather for a city
-  uweather add [city] --label [name]  Add a new location
-  uweather remove [label]           Remove a saved location
-  uweather locations                List all saved locations
-  uweather default [label]          Set default location
+// fail reports err in the selected output format and exits with its code
+func fail(err error) {
+	os.Exit(cmd.ReportError(os.Stderr, err, output))
+}
 
-Options:
-  --days N     Show N-day forecast (1-7, default: 1)
-  --label name Label for a new location
+// newProvider builds the weather provider selected by the cache flags
+func newProvider() (api.Provider, error) {
+	mode := cmd.CacheDefault
+	if noCacheFlag {
+		mode = cmd.CacheOff
+	} else if refreshFlag {
+		mode = cmd.CacheRefresh
+	}
 
-Examples:
+	provider, err := cmd.NewProvider(mode, timeoutFlag)
+	if err != nil {
+		return nil, err
+	}
+	client = provider
+	return provider, nil
+}
+
+// withReverseGeocoder builds the reverse geocoder and passes it to fn
+func withReverseGeocoder(fn func(api.ReverseGeocoder) error) error {
+	reverse, err := cmd.NewReverseGeocoder(timeoutFlag)
+	if err != nil {
+		return err
+	}
+	return fn(reverse)
+}
+
+const footer = `Examples:
   uweather                          # Show weather for default
   uweather home                     # Show weather for 'home'
   uweather Istanbul                 # Show weather for Istanbul
   uweather add Istanbul --label work
+  uweather add --lat 41.01 --lon 28.97 --label site3
+  uweather 41.01,28.97              # Weather at coordinates
   uweather --days 3                 # 3-day forecast for default
-`)
-}
+  uweather home --hourly --hours 12 # Next 12 hours for 'home'
+  uweather tag add home offices     # Tag a location
+  uweather --group offices          # Weather for every tagged location
+  uweather all                      # One table for all saved locations
+  uweather history home --from 2024-03-12 # Weather on a past day
+  uweather air home                 # Air quality and pollen
+  uweather marine izmir             # Waves and swell at the coast
+  uweather units imperial           # Use °F, mph and inches by default
+  uweather home --output=json       # Machine-readable output
+  uweather config set date_format iso
+  source <(uweather completion bash) # Tab completion, also zsh and fish
+
+Exit codes:
+  0 success, 1 error, 2 usage error, 3 location not found or inland,
+  4 label already exists, 5 ambiguous city name, 6 network error,
+  7 rate limited, 8 API error
+`
 
 func printNoDefaultMessage() {
-	fmt.Println(`uweather - Weather CLI Tool
+	fmt.Print(`uweather - Weather CLI Tool
 
 No default location set. Please add a city or set a default location.
 
@@ -211,6 +722,7 @@ Examples:
   uweather add Den Haag --label trip
 
 Options:
-  --days N     Show N-day forecast (1-7, default: 1)
+  --days N     Show N-day forecast (1-16, default: 1)
+  --past N     Also show the past N days
 `)
 }
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Units selects the measurement units requested from Open-Meteo and shown
+// by the renderers. Values use Open-Meteo's own parameter spelling.
+type Units struct {
+	Temperature   string `json:"temperature"`   // celsius or fahrenheit
+	Wind          string `json:"wind"`          // kmh, ms, mph or kn
+	Precipitation string `json:"precipitation"` // mm or inch
+	Length        string `json:"length"`        // metric or imperial: meters or feet, e.g. for wave heights
+}
+
+// Unit presets
+var (
+	Metric   = Units{Temperature: "celsius", Wind: "kmh", Precipitation: "mm", Length: "metric"}
+	Imperial = Units{Temperature: "fahrenheit", Wind: "mph", Precipitation: "inch", Length: "imperial"}
+)
+
+// unitAliases maps accepted spellings to a dimension and its canonical value
+var unitAliases = map[string][2]string{
+	"c":          {"temperature", "celsius"},
+	"celsius":    {"temperature", "celsius"},
+	"f":          {"temperature", "fahrenheit"},
+	"fahrenheit": {"temperature", "fahrenheit"},
+	"kmh":        {"wind", "kmh"},
+	"km/h":       {"wind", "kmh"},
+	"ms":         {"wind", "ms"},
+	"m/s":        {"wind", "ms"},
+	"mph":        {"wind", "mph"},
+	"kn":         {"wind", "kn"},
+	"knots":      {"wind", "kn"},
+	"mm":         {"precipitation", "mm"},
+	"in":         {"precipitation", "inch"},
+	"inch":       {"precipitation", "inch"},
+	"m":          {"length", "metric"},
+	"ft":         {"length", "imperial"},
+	"feet":       {"length", "imperial"},
+}
+
+// ParseUnits parses a units specification. It accepts the presets "metric"
+// and "imperial", or a comma separated list of presets and individual units
+// applied left to right on top of metric, e.g. "celsius,mph" or "imperial,mm".
+// Lengths, such as wave heights, are "m" or "ft".
+func ParseUnits(spec string) (Units, error) {
+	units := Metric
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "" {
+		return units, nil
+	}
+
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch part {
+		case "metric":
+			units = Metric
+			continue
+		case "imperial":
+			units = Imperial
+			continue
+		}
+
+		alias, ok := unitAliases[part]
+		if !ok {
+			return Units{}, fmt.Errorf("unknown unit %q (use metric, imperial, or a list such as celsius,mph,mm,ft)", part)
+		}
+		switch alias[0] {
+		case "temperature":
+			units.Temperature = alias[1]
+		case "wind":
+			units.Wind = alias[1]
+		case "precipitation":
+			units.Precipitation = alias[1]
+		case "length":
+			units.Length = alias[1]
+		}
+	}
+
+	return units, nil
+}
+
+// String returns the preset name or the comma separated unit list
+func (u Units) String() string {
+	switch u {
+	case Metric:
+		return "metric"
+	case Imperial:
+		return "imperial"
+	}
+	return u.Temperature + "," + u.Wind + "," + u.Precipitation + "," + u.LengthSymbol()
+}
+
+// TemperatureSymbol returns the suffix for temperatures, e.g. "°C"
+func (u Units) TemperatureSymbol() string {
+	if u.Temperature == "fahrenheit" {
+		return "°F"
+	}
+	return "°C"
+}
+
+// WindSymbol returns the suffix for wind speeds, e.g. "km/h"
+func (u Units) WindSymbol() string {
+	switch u.Wind {
+	case "ms":
+		return "m/s"
+	case "mph":
+		return "mph"
+	case "kn":
+		return "kn"
+	}
+	return "km/h"
+}
+
+// PrecipitationSymbol returns the suffix for precipitation amounts
+func (u Units) PrecipitationSymbol() string {
+	if u.Precipitation == "inch" {
+		return "in"
+	}
+	return "mm"
+}
+
+// LengthSymbol returns the suffix for lengths such as wave heights
+func (u Units) LengthSymbol() string {
+	if u.Length == "imperial" {
+		return "ft"
+	}
+	return "m"
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    Units
+		wantErr string
+	}{
+		{spec: "", want: Metric},
+		{spec: "metric", want: Metric},
+		{spec: " Imperial ", want: Imperial},
+		{spec: "celsius,mph", want: Units{Temperature: "celsius", Wind: "mph", Precipitation: "mm", Length: "metric"}},
+		{spec: "imperial,mm", want: Units{Temperature: "fahrenheit", Wind: "mph", Precipitation: "mm", Length: "imperial"}},
+		{spec: "f, km/h, in", want: Units{Temperature: "fahrenheit", Wind: "kmh", Precipitation: "inch", Length: "metric"}},
+		{spec: "m/s,knots", want: Units{Temperature: "celsius", Wind: "kn", Precipitation: "mm", Length: "metric"}},
+		{spec: "metric,ft", want: Units{Temperature: "celsius", Wind: "kmh", Precipitation: "mm", Length: "imperial"}},
+		{spec: "imperial,m", want: Units{Temperature: "fahrenheit", Wind: "mph", Precipitation: "inch", Length: "metric"}},
+		{spec: "mph,metric", want: Metric},
+		{spec: "kelvin", wantErr: `unknown unit "kelvin"`},
+		{spec: "celsius,", wantErr: `unknown unit ""`},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseUnits(tt.spec)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseUnits(%q) err = %v, want one containing %q", tt.spec, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseUnits(%q): %v", tt.spec, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseUnits(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+func TestUnitsStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		units Units
+		want  string
+	}{
+		{Metric, "metric"},
+		{Imperial, "imperial"},
+		{Units{Temperature: "celsius", Wind: "ms", Precipitation: "inch", Length: "imperial"}, "celsius,ms,inch,ft"},
+		{Units{Temperature: "fahrenheit", Wind: "kn", Precipitation: "mm", Length: "metric"}, "fahrenheit,kn,mm,m"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.units.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+		parsed, err := ParseUnits(tt.units.String())
+		if err != nil || parsed != tt.units {
+			t.Errorf("ParseUnits(%q) = %+v, %v; want %+v", tt.units.String(), parsed, err, tt.units)
+		}
+	}
+}
+
+func TestUnitSymbols(t *testing.T) {
+	tests := []struct {
+		spec                              string
+		temperature, wind, precip, length string
+	}{
+		{"metric", "°C", "km/h", "mm", "m"},
+		{"imperial", "°F", "mph", "in", "ft"},
+		{"ms", "°C", "m/s", "mm", "m"},
+		{"kn", "°C", "kn", "mm", "m"},
+	}
+
+	for _, tt := range tests {
+		u, err := ParseUnits(tt.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{u.TemperatureSymbol(), u.WindSymbol(), u.PrecipitationSymbol(), u.LengthSymbol()}
+		want := []string{tt.temperature, tt.wind, tt.precip, tt.length}
+		if strings.Join(got, " ") != strings.Join(want, " ") {
+			t.Errorf("%s symbols = %q, want %q", tt.spec, got, want)
+		}
+	}
+}
@@ -7,12 +7,44 @@ type Location struct {
 	Lat     float64 `json:"lat"`
 	Lon     float64 `json:"lon"`
 	Country string  `json:"country"`
+	// Timezone is an IANA zone name; empty uses the zone of the coordinates
+	Timezone string `json:"timezone,omitempty"`
+	// Tags group locations for the multi-location views, e.g. "offices"
+	Tags []string `json:"tags,omitempty"`
 }
 
 // LocationsData represents the JSON structure stored in file
 type LocationsData struct {
-	Locations []Location `json:"locations"`
-	Default   string     `json:"default"`
+	Version     int         `json:"version"` // schema version, see storage.CurrentVersion
+	Locations   []Location  `json:"locations"`
+	Default     string      `json:"default"`
+	API         *APIConfig  `json:"api,omitempty"`
+	Preferences Preferences `json:"preferences"`
+}
+
+// APIConfig overrides the Open-Meteo endpoints, e.g. for a self-hosted instance
+type APIConfig struct {
+	ForecastURL         string `json:"forecast_url,omitempty"`
+	GeocodingURL        string `json:"geocoding_url,omitempty"`
+	ArchiveURL          string `json:"archive_url,omitempty"`
+	AirQualityURL       string `json:"air_quality_url,omitempty"`
+	MarineURL           string `json:"marine_url,omitempty"`
+	ReverseGeocodingURL string `json:"reverse_geocoding_url,omitempty"`
+	ReverseGeocoder     string `json:"reverse_geocoder,omitempty"` // nominatim (default) or offline
+}
+
+// Preferences are the user's defaults for every command. Empty fields mean
+// the built-in default.
+type Preferences struct {
+	Units      string `json:"units,omitempty"`       // see ParseUnits
+	Language   string `json:"language,omitempty"`    // ISO 639-1 code for place names
+	DateFormat string `json:"date_format,omitempty"` // short, iso, eu or us
+	TimeFormat string `json:"time_format,omitempty"` // 24h or 12h
+	Theme      string `json:"theme,omitempty"`       // auto, dark, light or none
+	Days       int    `json:"days,omitempty"`        // default --days
+	Output     string `json:"output,omitempty"`      // default --output
+	CacheTTL   string `json:"cache_ttl,omitempty"`   // Go duration, e.g. "10m"
+	ShowAQI    string `json:"show_aqi,omitempty"`    // on or off
 }
 
 // GeocodingResponse represents Open-Meteo Geocoding API response
@@ -21,38 +53,121 @@ type GeocodingResponse struct {
 }
 
 type GeocodingResult struct {
-	Name      string  `json:"name"`
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
-	Country   string  `json:"country"`
-	Admin1    string  `json:"admin1"` // State/Province
+	Name        string  `json:"name"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"` // ISO 3166-1 alpha-2
+	Admin1      string  `json:"admin1"`       // State/Province
+	Population  int     `json:"population"`
 }
 
 // WeatherResponse represents Open-Meteo Weather API response
 type WeatherResponse struct {
-	CurrentWeather CurrentWeather     `json:"current_weather"`
-	Hourly         HourlyWeather      `json:"hourly"`
-	Daily          DailyWeather       `json:"daily"`
+	Timezone         string            `json:"timezone"`
+	UTCOffsetSeconds int               `json:"utc_offset_seconds"`
+	CurrentWeather   CurrentWeather    `json:"current"`
+	CurrentUnits     map[string]string `json:"current_units"` // unit of each current variable, e.g. "visibility": "m"
+	Hourly           HourlyWeather     `json:"hourly"`
+	Daily            DailyWeather      `json:"daily"`
 }
 
+// CurrentWeather holds the variables requested with the current= parameter
 type CurrentWeather struct {
-	Temperature   float64 `json:"temperature"`
-	Windspeed     float64 `json:"windspeed"`
-	Winddirection float64 `json:"winddirection"`
-	Weathercode   int     `json:"weathercode"`
-	Time          string  `json:"time"`
+	Time                string  `json:"time"`
+	Temperature         float64 `json:"temperature_2m"`
+	ApparentTemperature float64 `json:"apparent_temperature"`
+	Humidity            int     `json:"relative_humidity_2m"` // %
+	DewPoint            float64 `json:"dew_point_2m"`
+	SurfacePressure     float64 `json:"surface_pressure"` // hPa
+	CloudCover          int     `json:"cloud_cover"`      // %
+	Windspeed           float64 `json:"wind_speed_10m"`
+	Winddirection       float64 `json:"wind_direction_10m"`
+	WindGusts           float64 `json:"wind_gusts_10m"`
+	UVIndex             float64 `json:"uv_index"`
+	Visibility          float64 `json:"visibility"` // unit in CurrentUnits
+	Weathercode         int     `json:"weather_code"`
+	IsDay               int     `json:"is_day"` // 1 between sunrise and sunset
+}
+
+// HistoricalWeather represents an Open-Meteo historical weather API response
+type HistoricalWeather struct {
+	Timezone         string          `json:"timezone"`
+	UTCOffsetSeconds int             `json:"utc_offset_seconds"`
+	Daily            HistoricalDaily `json:"daily"`
+}
+
+// HistoricalDaily holds the daily values of the archive. The archive lags a
+// few days behind, and answers null for the days it has no data yet, so the
+// values are nil there.
+type HistoricalDaily struct {
+	Time             []string   `json:"time"`
+	TemperatureMax   []*float64 `json:"temperature_2m_max"`
+	TemperatureMin   []*float64 `json:"temperature_2m_min"`
+	Weathercode      []*int     `json:"weathercode"`
+	PrecipitationSum []*float64 `json:"precipitation_sum"`
+}
+
+// AirQuality represents an Open-Meteo air quality API response
+type AirQuality struct {
+	Timezone         string            `json:"timezone"`
+	UTCOffsetSeconds int               `json:"utc_offset_seconds"`
+	Current          CurrentAirQuality `json:"current"`
+}
+
+// CurrentAirQuality holds the current pollutant concentrations in µg/m³, the
+// air quality indexes and the pollen counts in grains/m³. Pollen is nil where
+// it is not forecast.
+type CurrentAirQuality struct {
+	Time            string   `json:"time"`
+	EuropeanAQI     *int     `json:"european_aqi"` // nil where not available, e.g. far out at sea
+	USAQI           *int     `json:"us_aqi"`
+	PM2_5           float64  `json:"pm2_5"`
+	PM10            float64  `json:"pm10"`
+	Ozone           float64  `json:"ozone"`
+	NitrogenDioxide float64  `json:"nitrogen_dioxide"`
+	AlderPollen     *float64 `json:"alder_pollen"`
+	BirchPollen     *float64 `json:"birch_pollen"`
+	GrassPollen     *float64 `json:"grass_pollen"`
+	MugwortPollen   *float64 `json:"mugwort_pollen"`
+	OlivePollen     *float64 `json:"olive_pollen"`
+	RagweedPollen   *float64 `json:"ragweed_pollen"`
+}
+
+// Marine represents an Open-Meteo marine API response
+type Marine struct {
+	Timezone         string            `json:"timezone"`
+	UTCOffsetSeconds int               `json:"utc_offset_seconds"`
+	Current          CurrentMarine     `json:"current"`
+	CurrentUnits     map[string]string `json:"current_units"` // e.g. "wave_height": "m"
+}
+
+// CurrentMarine holds the current sea state. Fields are nil where the API has
+// no data, such as inland.
+type CurrentMarine struct {
+	Time                  string   `json:"time"`
+	WaveHeight            *float64 `json:"wave_height"`
+	WaveDirection         *float64 `json:"wave_direction"` // degrees the waves come from
+	WavePeriod            *float64 `json:"wave_period"`    // seconds
+	SwellWaveHeight       *float64 `json:"swell_wave_height"`
+	SwellWaveDirection    *float64 `json:"swell_wave_direction"`
+	SwellWavePeriod       *float64 `json:"swell_wave_period"`
+	WindWaveHeight        *float64 `json:"wind_wave_height"`
+	SeaSurfaceTemperature *float64 `json:"sea_surface_temperature"`
 }
 
 type HourlyWeather struct {
-	Time               []string  `json:"time"`
-	Temperature_2m     []float64 `json:"temperature_2m"`
-	Relativehumidity_2m []int    `json:"relativehumidity_2m"`
+	Time                     []string  `json:"time"`
+	Temperature_2m           []float64 `json:"temperature_2m"`
+	Relativehumidity_2m      []int     `json:"relativehumidity_2m"`
+	PrecipitationProbability []int     `json:"precipitation_probability,omitempty"`
+	Weathercode              []int     `json:"weathercode,omitempty"`
 }
 
 type DailyWeather struct {
-	Time            []string  `json:"time"`
-	TemperatureMax  []float64 `json:"temperature_2m_max"`
-	TemperatureMin  []float64 `json:"temperature_2m_min"`
-	Weathercode     []int     `json:"weathercode"`
+	Time             []string  `json:"time"`
+	TemperatureMax   []float64 `json:"temperature_2m_max"`
+	TemperatureMin   []float64 `json:"temperature_2m_min"`
+	Weathercode      []int     `json:"weathercode"`
 	PrecipitationSum []float64 `json:"precipitation_sum"`
 }
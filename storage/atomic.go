@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// writeFileAtomic writes data to a temp file in the same directory, fsyncs
+// it and renames it over path, so readers see either the old or the new
+// content and a crash never leaves a half-written file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName) // no-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself. Not every platform can fsync a directory,
+	// so failures here are ignored.
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+	return nil
+}
+
+// withLock runs fn while holding the advisory lock on the config file
+func withLock(fn func() error) error {
+	if err := EnsureConfigDir(); err != nil {
+		return err
+	}
+	configFile, err := GetConfigFilePath()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(configFile + lockSuffix)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return fn()
+}
@@ -0,0 +1,85 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// GetLegacyPath returns the pre-XDG config directory, ~/.uweather
+func GetLegacyPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not find home directory: %w", err)
+	}
+	return filepath.Join(homeDir, legacyDir), nil
+}
+
+// MigrateLegacyHome moves an existing ~/.uweather install to the XDG
+// locations. It does nothing when a directory override is active, when there
+// is no legacy install, or when the new config file already exists. It
+// reports the legacy directory it migrated from, or "".
+func MigrateLegacyHome() (string, error) {
+	if getHome() != "" {
+		return "", nil
+	}
+
+	legacy, err := GetLegacyPath()
+	if err != nil {
+		return "", err
+	}
+	legacyFile := filepath.Join(legacy, configFile)
+	if _, err := os.Stat(legacyFile); err != nil {
+		return "", nil
+	}
+
+	configFilePath, err := GetConfigFilePath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(configFilePath); err == nil {
+		return "", nil
+	}
+
+	if err := EnsureConfigDir(); err != nil {
+		return "", err
+	}
+
+	err = withLock(func() error {
+		if err := moveFile(legacyFile, configFilePath); err != nil {
+			return fmt.Errorf("failed to move %s: %w", legacyFile, err)
+		}
+		if err := moveFile(legacyFile+backupSuffix, configFilePath+backupSuffix); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move %s: %w", legacyFile+backupSuffix, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	// Cached responses are cheap to refetch, so they are dropped rather than
+	// moved across file systems
+	os.RemoveAll(filepath.Join(legacy, cacheDir))
+	os.Remove(legacyFile + lockSuffix)
+	os.Remove(legacy) // only succeeds once the directory is empty
+
+	return legacy, nil
+}
+
+// moveFile renames src to dst, copying when they are on different file
+// systems
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(dst, data, 0644); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
@@ -0,0 +1,38 @@
+//go:build !unix
+
+package storage
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// staleLockAge is how old a lock file may get before it is assumed to be
+// left over from a crashed process
+const staleLockAge = 30 * time.Second
+
+// lockFile takes an exclusive lock by creating path, waiting while another
+// process holds it
+func lockFile(path string) (func(), error) {
+	deadline := time.Now().Add(2 * staleLockAge)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		if info, statErr := os.Stat(path); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timed out waiting for lock file %s", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,171 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// CurrentVersion is the config schema version written by this build
+const CurrentVersion = 2
+
+// migration upgrades the raw config document from version From to From+1.
+// Migrations work on the decoded JSON rather than on models.LocationsData so
+// that old layouts can be read even after the structs have changed.
+type migration struct {
+	From        int
+	Description string
+	Apply       func(doc map[string]any) error
+}
+
+// migrations must be sorted by From and cover every version below
+// CurrentVersion
+var migrations = []migration{
+	{
+		From:        0,
+		Description: "add schema version field",
+		Apply: func(doc map[string]any) error {
+			if doc["locations"] == nil {
+				doc["locations"] = []any{}
+			}
+			return nil
+		},
+	},
+	{
+		From:        1,
+		Description: `move "units" and "cache.ttl" into "preferences"`,
+		Apply: func(doc map[string]any) error {
+			prefs, _ := doc["preferences"].(map[string]any)
+			if prefs == nil {
+				prefs = map[string]any{}
+			}
+			if units, ok := doc["units"].(string); ok && units != "" {
+				prefs["units"] = units
+			}
+			if cache, ok := doc["cache"].(map[string]any); ok {
+				if ttl, ok := cache["ttl"].(string); ok && ttl != "" {
+					prefs["cache_ttl"] = ttl
+				}
+			}
+			delete(doc, "units")
+			delete(doc, "cache")
+			doc["preferences"] = prefs
+			return nil
+		},
+	},
+}
+
+// MigrationPlan describes how the config file is upgraded to CurrentVersion
+type MigrationPlan struct {
+	Path        string
+	FromVersion int
+	ToVersion   int
+	Steps       []string // descriptions of the migrations applied, in order
+	Before      []byte   // file content before the upgrade
+	After       []byte   // file content after the upgrade
+}
+
+// NeedsMigration reports whether the plan changes anything
+func (p *MigrationPlan) NeedsMigration() bool {
+	return len(p.Steps) > 0
+}
+
+// PlanMigration computes the upgrade of the config file without writing it
+func PlanMigration() (*MigrationPlan, error) {
+	configFile, err := GetConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &MigrationPlan{Path: configFile, ToVersion: CurrentVersion}
+
+	raw, err := os.ReadFile(configFile)
+	if os.IsNotExist(err) {
+		plan.FromVersion = CurrentVersion
+		return plan, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	plan.Before = raw
+
+	data, from, steps, err := decodeLocations(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	plan.FromVersion = from
+	plan.Steps = steps
+
+	plan.After, err = json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal data: %w", err)
+	}
+	return plan, nil
+}
+
+// Migrate upgrades the config file to CurrentVersion and returns what was
+// done. The previous file is kept as the backup.
+func Migrate() (*MigrationPlan, error) {
+	var plan *MigrationPlan
+	err := withLock(func() error {
+		var err error
+		plan, err = PlanMigration()
+		if err != nil || !plan.NeedsMigration() {
+			return err
+		}
+
+		data, err := LoadLocations()
+		if err != nil {
+			return err
+		}
+		return saveLocations(data)
+	})
+	return plan, err
+}
+
+// decodeLocations parses a config file of any supported version, applying
+// migrations step by step. It returns the upgraded data, the version found
+// in the file and the descriptions of the migrations applied.
+func decodeLocations(raw []byte) (*models.LocationsData, int, []string, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return nil, 0, nil, err
+	}
+	if doc == nil {
+		return nil, 0, nil, fmt.Errorf("config file is not a JSON object")
+	}
+
+	version := 0
+	if v, ok := doc["version"].(float64); ok {
+		version = int(v)
+	}
+	if version > CurrentVersion {
+		return nil, version, nil, fmt.Errorf("config file version %d is newer than this uweather supports (%d); please upgrade uweather", version, CurrentVersion)
+	}
+
+	from := version
+	var steps []string
+	for _, m := range migrations {
+		if m.From != version {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, from, steps, fmt.Errorf("migration from version %d failed: %w", m.From, err)
+		}
+		version++
+		doc["version"] = version
+		steps = append(steps, fmt.Sprintf("v%d -> v%d: %s", m.From, version, m.Description))
+	}
+
+	upgraded, err := json.Marshal(doc)
+	if err != nil {
+		return nil, from, steps, err
+	}
+	var data models.LocationsData
+	if err := json.Unmarshal(upgraded, &data); err != nil {
+		return nil, from, steps, err
+	}
+	return &data, from, steps, nil
+}
@@ -0,0 +1,187 @@
+package storage
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+func TestDecodeLocations(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		wantFrom    int
+		wantSteps   int
+		wantLabels  []string
+		wantDefault string
+		wantPrefs   models.Preferences
+		wantErr     string
+	}{
+		{
+			name:        "v0 without version",
+			raw:         `{"locations": [{"label": "home", "city": "Istanbul", "lat": 41.01, "lon": 28.95}], "default": "home"}`,
+			wantFrom:    0,
+			wantSteps:   2,
+			wantLabels:  []string{"home"},
+			wantDefault: "home",
+		},
+		{
+			name:      "v0 without locations",
+			raw:       `{}`,
+			wantFrom:  0,
+			wantSteps: 2,
+		},
+		{
+			name:       "v1 with units and cache ttl",
+			raw:        `{"version": 1, "locations": [{"label": "work"}], "units": "imperial", "cache": {"ttl": "15m"}}`,
+			wantFrom:   1,
+			wantSteps:  1,
+			wantLabels: []string{"work"},
+			wantPrefs:  models.Preferences{Units: "imperial", CacheTTL: "15m"},
+		},
+		{
+			name:      "v1 keeps existing preferences",
+			raw:       `{"version": 1, "locations": [], "units": "celsius,mph", "preferences": {"theme": "none"}}`,
+			wantFrom:  1,
+			wantSteps: 1,
+			wantPrefs: models.Preferences{Units: "celsius,mph", Theme: "none"},
+		},
+		{
+			name:       "v1 without the moved fields",
+			raw:        `{"version": 1, "locations": [{"label": "home"}]}`,
+			wantFrom:   1,
+			wantSteps:  1,
+			wantLabels: []string{"home"},
+		},
+		{
+			name:        "current version",
+			raw:         `{"version": 2, "locations": [{"label": "home"}], "default": "home", "preferences": {"days": 3}}`,
+			wantFrom:    2,
+			wantLabels:  []string{"home"},
+			wantDefault: "home",
+			wantPrefs:   models.Preferences{Days: 3},
+		},
+		{
+			name:    "newer version",
+			raw:     `{"version": 3, "locations": []}`,
+			wantErr: "newer than this uweather supports",
+		},
+		{
+			name:    "not an object",
+			raw:     `null`,
+			wantErr: "not a JSON object",
+		},
+		{
+			name:    "invalid json",
+			raw:     `{"version": 2,`,
+			wantErr: "unexpected end of JSON input",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, from, steps, err := decodeLocations([]byte(tt.raw))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if from != tt.wantFrom || len(steps) != tt.wantSteps {
+				t.Errorf("from v%d with steps %q, want v%d with %d steps", from, steps, tt.wantFrom, tt.wantSteps)
+			}
+			if data.Version != CurrentVersion {
+				t.Errorf("version = %d, want %d", data.Version, CurrentVersion)
+			}
+			if data.Locations == nil {
+				t.Error("locations is nil")
+			}
+			var labels []string
+			for _, loc := range data.Locations {
+				labels = append(labels, loc.Label)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", labels, tt.wantLabels)
+			}
+			if data.Default != tt.wantDefault {
+				t.Errorf("default = %q, want %q", data.Default, tt.wantDefault)
+			}
+			if data.Preferences != tt.wantPrefs {
+				t.Errorf("preferences = %+v, want %+v", data.Preferences, tt.wantPrefs)
+			}
+		})
+	}
+}
+
+func TestMigrationsCoverEveryVersion(t *testing.T) {
+	if len(migrations) != CurrentVersion {
+		t.Fatalf("%d migrations for version %d", len(migrations), CurrentVersion)
+	}
+	for i, m := range migrations {
+		if m.From != i {
+			t.Errorf("migration %d starts from v%d", i, m.From)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	useTempHome(t)
+	path := configFilePath(t)
+	if err := EnsureConfigDir(); err != nil {
+		t.Fatal(err)
+	}
+	v0 := `{"locations": [{"label": "home", "city": "Istanbul"}], "default": "home", "units": "imperial"}`
+	if err := os.WriteFile(path, []byte(v0), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err := PlanMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.NeedsMigration() || plan.FromVersion != 0 || plan.ToVersion != CurrentVersion {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if raw, _ := os.ReadFile(path); string(raw) != v0 {
+		t.Error("planning changed the file")
+	}
+
+	if _, err := Migrate(); err != nil {
+		t.Fatal(err)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var migrated struct {
+		Version     int                `json:"version"`
+		Units       *string            `json:"units"`
+		Preferences models.Preferences `json:"preferences"`
+	}
+	if err := json.Unmarshal(raw, &migrated); err != nil {
+		t.Fatal(err)
+	}
+	if migrated.Version != CurrentVersion || migrated.Units != nil || migrated.Preferences.Units != "imperial" {
+		t.Errorf("migrated file:\n%s", raw)
+	}
+	if backup, _ := os.ReadFile(path + backupSuffix); string(backup) != v0 {
+		t.Errorf("backup = %s, want the v0 file", backup)
+	}
+
+	// A second run has nothing to do
+	plan, err = PlanMigration()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.NeedsMigration() {
+		t.Errorf("second plan has steps %q", plan.Steps)
+	}
+}
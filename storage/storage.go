@@ -2,25 +2,74 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/ugur-claw/uweather/models"
 )
 
 const (
-	configDir  = ".uweather"
+	appDir     = "uweather"
+	legacyDir  = ".uweather"
 	configFile = "locations.json"
+	cacheDir   = "cache"
+	stateDir   = "state"
+
+	backupSuffix = ".bak"
+	lockSuffix   = ".lock"
 )
 
-// GetConfigPath returns the path to the config directory
-func GetConfigPath() (string, error) {
+// EnvHome names the environment variable that puts config, cache and state
+// under a single directory, overriding the XDG locations
+const EnvHome = "UWEATHER_HOME"
+
+// homeOverride is set by SetHome (--config-dir) and wins over EnvHome
+var homeOverride string
+
+// Errors returned for missing or conflicting locations; match with errors.Is
+var (
+	ErrLocationNotFound = errors.New("not found")
+	ErrLocationExists   = errors.New("already exists")
+	ErrNoDefault        = errors.New("no default location set")
+)
+
+// SetHome puts config, cache and state under dir, like UWEATHER_HOME.
+// An empty dir restores the default locations.
+func SetHome(dir string) {
+	homeOverride = dir
+}
+
+// getHome returns the single-directory override, or "" for XDG layout
+func getHome() string {
+	if homeOverride != "" {
+		return homeOverride
+	}
+	return os.Getenv(EnvHome)
+}
+
+// xdgDir returns $<env>/uweather, or ~/<fallback>/uweather when the
+// variable is unset. Relative values are ignored, as the XDG spec requires.
+func xdgDir(env, fallback string) (string, error) {
+	if dir := os.Getenv(env); dir != "" && filepath.IsAbs(dir) {
+		return filepath.Join(dir, appDir), nil
+	}
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
 		return "", fmt.Errorf("could not find home directory: %w", err)
 	}
-	return filepath.Join(homeDir, configDir), nil
+	return filepath.Join(homeDir, fallback, appDir), nil
+}
+
+// GetConfigPath returns the path to the config directory:
+// $XDG_CONFIG_HOME/uweather by default
+func GetConfigPath() (string, error) {
+	if home := getHome(); home != "" {
+		return home, nil
+	}
+	return xdgDir("XDG_CONFIG_HOME", ".config")
 }
 
 // GetConfigFilePath returns the full path to the config file
@@ -32,6 +81,24 @@ func GetConfigFilePath() (string, error) {
 	return filepath.Join(configDir, configFile), nil
 }
 
+// GetCachePath returns the directory holding cached API responses:
+// $XDG_CACHE_HOME/uweather by default
+func GetCachePath() (string, error) {
+	if home := getHome(); home != "" {
+		return filepath.Join(home, cacheDir), nil
+	}
+	return xdgDir("XDG_CACHE_HOME", ".cache")
+}
+
+// GetStatePath returns the directory for state such as history:
+// $XDG_STATE_HOME/uweather by default
+func GetStatePath() (string, error) {
+	if home := getHome(); home != "" {
+		return filepath.Join(home, stateDir), nil
+	}
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
 // EnsureConfigDir ensures the config directory exists
 func EnsureConfigDir() error {
 	configDir, err := GetConfigPath()
@@ -41,7 +108,8 @@ func EnsureConfigDir() error {
 	return os.MkdirAll(configDir, 0755)
 }
 
-// LoadLocations loads locations from the config file
+// LoadLocations loads locations from the config file. If the file cannot be
+// parsed, the backup written by the previous save is used instead.
 func LoadLocations() (*models.LocationsData, error) {
 	configFile, err := GetConfigFilePath()
 	if err != nil {
@@ -51,6 +119,7 @@ func LoadLocations() (*models.LocationsData, error) {
 	// If file doesn't exist, return empty data
 	if _, err := os.Stat(configFile); os.IsNotExist(err) {
 		return &models.LocationsData{
+			Version:   CurrentVersion,
 			Locations: []models.Location{},
 			Default:   "",
 		}, nil
@@ -61,16 +130,43 @@ func LoadLocations() (*models.LocationsData, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	var locations models.LocationsData
-	if err := json.Unmarshal(data, &locations); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	// Older files are upgraded in memory; the next save writes them back
+	locations, version, _, err := decodeLocations(data)
+	if err != nil {
+		if version > CurrentVersion {
+			return nil, err
+		}
+		backup, backupErr := loadBackup(configFile)
+		if backupErr != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+		return backup, nil
+	}
+
+	return locations, nil
+}
+
+// loadBackup reads the backup of the config file
+func loadBackup(configFile string) (*models.LocationsData, error) {
+	data, err := os.ReadFile(configFile + backupSuffix)
+	if err != nil {
+		return nil, err
 	}
 
-	return &locations, nil
+	locations, _, _, err := decodeLocations(data)
+	return locations, err
 }
 
 // SaveLocations saves locations to the config file
 func SaveLocations(data *models.LocationsData) error {
+	return withLock(func() error {
+		return saveLocations(data)
+	})
+}
+
+// saveLocations replaces the config file atomically, keeping the previous
+// version as a backup. Callers must hold the config lock.
+func saveLocations(data *models.LocationsData) error {
 	if err := EnsureConfigDir(); err != nil {
 		return err
 	}
@@ -80,29 +176,60 @@ func SaveLocations(data *models.LocationsData) error {
 		return err
 	}
 
+	data.Version = CurrentVersion
 	jsonData, err := json.MarshalIndent(data, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
 
-	if err := os.WriteFile(configFile, jsonData, 0644); err != nil {
+	// Only a file that still loads is worth keeping as the backup
+	if current, err := os.ReadFile(configFile); err == nil && isLoadable(current) {
+		if err := writeFileAtomic(configFile+backupSuffix, current, 0644); err != nil {
+			return fmt.Errorf("failed to write config backup: %w", err)
+		}
+	}
+
+	if err := writeFileAtomic(configFile, jsonData, 0644); err != nil {
 		return fmt.Errorf("failed to write config file: %w", err)
 	}
 
 	return nil
 }
 
+// isLoadable reports whether raw is a config file LoadLocations can read
+func isLoadable(raw []byte) bool {
+	_, _, _, err := decodeLocations(raw)
+	return err == nil
+}
+
+// updateLocations runs a read-modify-write cycle on the config file while
+// holding the config lock, so concurrent uweather processes do not lose each
+// other's changes
+func updateLocations(update func(data *models.LocationsData) error) error {
+	return withLock(func() error {
+		data, err := LoadLocations()
+		if err != nil {
+			return err
+		}
+		if err := update(data); err != nil {
+			return err
+		}
+		return saveLocations(data)
+	})
+}
+
 // AddLocation adds a new location
 func AddLocation(label, city string, lat, lon float64, country string) error {
-	data, err := LoadLocations()
-	if err != nil {
-		return err
-	}
+	return updateLocations(func(data *models.LocationsData) error {
+		return addLocation(data, label, city, lat, lon, country)
+	})
+}
 
+func addLocation(data *models.LocationsData, label, city string, lat, lon float64, country string) error {
 	// Check if label already exists
 	for _, loc := range data.Locations {
 		if loc.Label == label {
-			return fmt.Errorf("label '%s' already exists. Use a different label or remove it first.", label)
+			return fmt.Errorf("label '%s' %w. Use a different label or remove it first", label, ErrLocationExists)
 		}
 	}
 
@@ -121,16 +248,17 @@ func AddLocation(label, city string, lat, lon float64, country string) error {
 		data.Default = label
 	}
 
-	return SaveLocations(data)
+	return nil
 }
 
 // RemoveLocation removes a location by label
 func RemoveLocation(label string) error {
-	data, err := LoadLocations()
-	if err != nil {
-		return err
-	}
+	return updateLocations(func(data *models.LocationsData) error {
+		return removeLocation(data, label)
+	})
+}
 
+func removeLocation(data *models.LocationsData, label string) error {
 	found := false
 	newLocations := make([]models.Location, 0)
 	for _, loc := range data.Locations {
@@ -142,7 +270,7 @@ func RemoveLocation(label string) error {
 	}
 
 	if !found {
-		return fmt.Errorf("label '%s' not found", label)
+		return fmt.Errorf("label '%s' %w", label, ErrLocationNotFound)
 	}
 
 	data.Locations = newLocations
@@ -156,7 +284,7 @@ func RemoveLocation(label string) error {
 		}
 	}
 
-	return SaveLocations(data)
+	return nil
 }
 
 // GetLocation returns a location by label
@@ -166,13 +294,21 @@ func GetLocation(label string) (*models.Location, error) {
 		return nil, err
 	}
 
-	for _, loc := range data.Locations {
+	if i := findLocation(data, label); i >= 0 {
+		return &data.Locations[i], nil
+	}
+
+	return nil, fmt.Errorf("label '%s' %w", label, ErrLocationNotFound)
+}
+
+// findLocation returns the index of the location with label, or -1
+func findLocation(data *models.LocationsData, label string) int {
+	for i, loc := range data.Locations {
 		if loc.Label == label {
-			return &loc, nil
+			return i
 		}
 	}
-
-	return nil, fmt.Errorf("label '%s' not found", label)
+	return -1
 }
 
 // GetDefaultLocation returns the default location
@@ -183,7 +319,7 @@ func GetDefaultLocation() (*models.Location, error) {
 	}
 
 	if data.Default == "" {
-		return nil, fmt.Errorf("no default location set. Use 'uweather default [label]' to set one")
+		return nil, fmt.Errorf("%w. Use 'uweather default [label]' to set one", ErrNoDefault)
 	}
 
 	return GetLocation(data.Default)
@@ -191,19 +327,125 @@ func GetDefaultLocation() (*models.Location, error) {
 
 // SetDefaultLocation sets the default location by label
 func SetDefaultLocation(label string) error {
-	// First check if label exists
-	_, err := GetLocation(label)
-	if err != nil {
-		return err
-	}
+	return updateLocations(func(data *models.LocationsData) error {
+		// First check if label exists
+		if findLocation(data, label) < 0 {
+			return fmt.Errorf("label '%s' %w", label, ErrLocationNotFound)
+		}
+
+		data.Default = label
+		return nil
+	})
+}
+
+// RenameLocation changes the label of a location, keeping its position and
+// default status
+func RenameLocation(oldLabel, newLabel string) error {
+	return updateLocations(func(data *models.LocationsData) error {
+		i := findLocation(data, oldLabel)
+		if i < 0 {
+			return fmt.Errorf("label '%s' %w", oldLabel, ErrLocationNotFound)
+		}
+		if oldLabel == newLabel {
+			return nil
+		}
+		if findLocation(data, newLabel) >= 0 {
+			return fmt.Errorf("label '%s' %w", newLabel, ErrLocationExists)
+		}
+
+		data.Locations[i].Label = newLabel
+		if data.Default == oldLabel {
+			data.Default = newLabel
+		}
+		return nil
+	})
+}
+
+// UpdateLocation changes the fields of a location under the config lock.
+// The label cannot be changed this way; use RenameLocation.
+func UpdateLocation(label string, update func(loc *models.Location) error) error {
+	return updateLocations(func(data *models.LocationsData) error {
+		i := findLocation(data, label)
+		if i < 0 {
+			return fmt.Errorf("label '%s' %w", label, ErrLocationNotFound)
+		}
+		if err := update(&data.Locations[i]); err != nil {
+			return err
+		}
+		data.Locations[i].Label = label
+		return nil
+	})
+}
 
+// MoveLocation moves a location next to anchor: right after it when after is
+// true, right before it otherwise
+func MoveLocation(label, anchor string, after bool) error {
+	return updateLocations(func(data *models.LocationsData) error {
+		from := findLocation(data, label)
+		if from < 0 {
+			return fmt.Errorf("label '%s' %w", label, ErrLocationNotFound)
+		}
+		if findLocation(data, anchor) < 0 {
+			return fmt.Errorf("label '%s' %w", anchor, ErrLocationNotFound)
+		}
+		if label == anchor {
+			return nil
+		}
+
+		loc := data.Locations[from]
+		data.Locations = slices.Delete(data.Locations, from, from+1)
+		to := findLocation(data, anchor)
+		if after {
+			to++
+		}
+		data.Locations = slices.Insert(data.Locations, to, loc)
+		return nil
+	})
+}
+
+// TagLocation adds tags to a location
+func TagLocation(label string, tags ...string) error {
+	return UpdateLocation(label, func(loc *models.Location) error {
+		for _, tag := range tags {
+			if !slices.Contains(loc.Tags, tag) {
+				loc.Tags = append(loc.Tags, tag)
+			}
+		}
+		return nil
+	})
+}
+
+// UntagLocation removes tags from a location
+func UntagLocation(label string, tags ...string) error {
+	return UpdateLocation(label, func(loc *models.Location) error {
+		for _, tag := range tags {
+			i := slices.Index(loc.Tags, tag)
+			if i < 0 {
+				return fmt.Errorf("tag '%s' on '%s' %w", tag, label, ErrLocationNotFound)
+			}
+			loc.Tags = slices.Delete(loc.Tags, i, i+1)
+		}
+		return nil
+	})
+}
+
+// LocationsByTag returns the locations tagged with tag, in their saved order
+func LocationsByTag(tag string) ([]models.Location, error) {
 	data, err := LoadLocations()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	data.Default = label
-	return SaveLocations(data)
+	var locations []models.Location
+	for _, loc := range data.Locations {
+		if slices.Contains(loc.Tags, tag) {
+			locations = append(locations, loc)
+		}
+	}
+	if len(locations) == 0 {
+		return nil, fmt.Errorf("group '%s' %w", tag, ErrLocationNotFound)
+	}
+	return locations, nil
 }
 
 // ListLocations returns all saved locations
@@ -215,3 +457,19 @@ func ListLocations() ([]models.Location, string, error) {
 
 	return data.Locations, data.Default, nil
 }
+
+// GetPreferences returns the stored preferences
+func GetPreferences() (models.Preferences, error) {
+	data, err := LoadLocations()
+	if err != nil {
+		return models.Preferences{}, err
+	}
+	return data.Preferences, nil
+}
+
+// UpdatePreferences changes the stored preferences under the config lock
+func UpdatePreferences(update func(prefs *models.Preferences) error) error {
+	return updateLocations(func(data *models.LocationsData) error {
+		return update(&data.Preferences)
+	})
+}
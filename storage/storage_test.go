@@ -0,0 +1,409 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// useTempHome keeps the config of a test in its own directory
+func useTempHome(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	SetHome(dir)
+	t.Cleanup(func() { SetHome("") })
+	return dir
+}
+
+func configFilePath(t *testing.T) string {
+	t.Helper()
+	path, err := GetConfigFilePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func labels(t *testing.T) []string {
+	t.Helper()
+	data, err := LoadLocations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var labels []string
+	for _, loc := range data.Locations {
+		labels = append(labels, loc.Label)
+	}
+	return labels
+}
+
+func TestPaths(t *testing.T) {
+	tests := []struct {
+		name                 string
+		env                  map[string]string
+		override             string
+		config, cache, state string
+	}{
+		{
+			name:   "xdg defaults",
+			env:    map[string]string{"HOME": "/home/u"},
+			config: "/home/u/.config/uweather",
+			cache:  "/home/u/.cache/uweather",
+			state:  "/home/u/.local/state/uweather",
+		},
+		{
+			name: "xdg variables",
+			env: map[string]string{
+				"HOME":            "/home/u",
+				"XDG_CONFIG_HOME": "/xdg/config",
+				"XDG_CACHE_HOME":  "/xdg/cache",
+				"XDG_STATE_HOME":  "/xdg/state",
+			},
+			config: "/xdg/config/uweather",
+			cache:  "/xdg/cache/uweather",
+			state:  "/xdg/state/uweather",
+		},
+		{
+			name:   "relative xdg variables are ignored",
+			env:    map[string]string{"HOME": "/home/u", "XDG_STATE_HOME": "state"},
+			config: "/home/u/.config/uweather",
+			cache:  "/home/u/.cache/uweather",
+			state:  "/home/u/.local/state/uweather",
+		},
+		{
+			name:   "uweather home",
+			env:    map[string]string{"HOME": "/home/u", EnvHome: "/data", "XDG_STATE_HOME": "/xdg/state"},
+			config: "/data",
+			cache:  "/data/cache",
+			state:  "/data/state",
+		},
+		{
+			name:     "config dir wins over uweather home",
+			env:      map[string]string{"HOME": "/home/u", EnvHome: "/data"},
+			override: "/tmp/uw",
+			config:   "/tmp/uw",
+			cache:    "/tmp/uw/cache",
+			state:    "/tmp/uw/state",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{EnvHome, "XDG_CONFIG_HOME", "XDG_CACHE_HOME", "XDG_STATE_HOME"} {
+				t.Setenv(name, "")
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			SetHome(tt.override)
+			t.Cleanup(func() { SetHome("") })
+
+			for _, path := range []struct {
+				what string
+				get  func() (string, error)
+				want string
+			}{
+				{"config", GetConfigPath, tt.config},
+				{"cache", GetCachePath, tt.cache},
+				{"state", GetStatePath, tt.state},
+			} {
+				got, err := path.get()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != path.want {
+					t.Errorf("%s path = %s, want %s", path.what, got, path.want)
+				}
+			}
+		})
+	}
+}
+
+func TestSaveKeepsBackup(t *testing.T) {
+	useTempHome(t)
+	path := configFilePath(t)
+
+	if err := AddLocation("home", "Istanbul", 41.01, 28.95, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + backupSuffix); !os.IsNotExist(err) {
+		t.Errorf("backup exists after the first save: %v", err)
+	}
+	if err := AddLocation("work", "Ankara", 39.92, 32.85, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+
+	backup, err := loadBackup(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backup.Locations) != 1 || backup.Locations[0].Label != "home" {
+		t.Errorf("backup holds %+v, want the state before the last save", backup.Locations)
+	}
+}
+
+func TestLoadLocationsRecoversFromBackup(t *testing.T) {
+	tests := []struct {
+		name    string
+		primary string
+	}{
+		{"truncated", `{"version": 2, "locations": [{"label": "ho`},
+		{"empty", ""},
+		{"not an object", `[1, 2, 3]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			path := configFilePath(t)
+			if err := AddLocation("home", "Istanbul", 41.01, 28.95, "Türkiye"); err != nil {
+				t.Fatal(err)
+			}
+			if err := AddLocation("work", "Ankara", 39.92, 32.85, "Türkiye"); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := os.WriteFile(path, []byte(tt.primary), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := labels(t); len(got) != 1 || got[0] != "home" {
+				t.Fatalf("recovered labels %v, want [home]", got)
+			}
+
+			// The next save starts from the backup and replaces the corrupt file,
+			// which is not kept as the new backup
+			if err := AddLocation("lab", "Izmir", 38.42, 27.14, "Türkiye"); err != nil {
+				t.Fatal(err)
+			}
+			if got := labels(t); len(got) != 2 || got[1] != "lab" {
+				t.Errorf("labels after saving %v, want [home lab]", got)
+			}
+			backup, err := loadBackup(path)
+			if err != nil {
+				t.Fatalf("backup was replaced by the corrupt file: %v", err)
+			}
+			if len(backup.Locations) != 1 {
+				t.Errorf("backup holds %+v", backup.Locations)
+			}
+		})
+	}
+}
+
+func TestLoadLocationsWithoutBackup(t *testing.T) {
+	useTempHome(t)
+	path := configFilePath(t)
+	if err := EnsureConfigDir(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := LoadLocations(); err == nil {
+		t.Error("a corrupt file without a backup loaded without error")
+	}
+}
+
+func TestLoadLocationsNewerVersion(t *testing.T) {
+	useTempHome(t)
+	path := configFilePath(t)
+	if err := AddLocation("home", "Istanbul", 41.01, 28.95, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddLocation("work", "Ankara", 39.92, 32.85, "Türkiye"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A file from a newer uweather is not replaced by the older backup
+	newer := fmt.Sprintf(`{"version": %d, "locations": []}`, CurrentVersion+1)
+	if err := os.WriteFile(path, []byte(newer), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadLocations(); err == nil {
+		t.Error("a newer config file loaded without error")
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	useTempHome(t)
+
+	const writers = 20
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- AddLocation(fmt.Sprintf("loc%d", i), "Istanbul", 41.01, 28.95, "Türkiye")
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Every read-modify-write ran under the lock, so no update was lost
+	if got := labels(t); len(got) != writers {
+		t.Errorf("got %d locations, want %d: %v", len(got), writers, got)
+	}
+}
+
+// addLocations saves a location per label, in order, the first as default
+func addLocations(t *testing.T, labels ...string) {
+	t.Helper()
+	for _, label := range labels {
+		if err := AddLocation(label, "Istanbul", 41.01, 28.95, "Türkiye"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := SetDefaultLocation(labels[0]); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRenameLocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		old, new    string
+		wantErr     error
+		wantLabels  []string
+		wantDefault string
+	}{
+		{name: "default", old: "home", new: "house", wantLabels: []string{"house", "work", "lab"}, wantDefault: "house"},
+		{name: "other", old: "work", new: "office", wantLabels: []string{"home", "office", "lab"}, wantDefault: "home"},
+		{name: "same label", old: "work", new: "work", wantLabels: []string{"home", "work", "lab"}, wantDefault: "home"},
+		{name: "taken label", old: "work", new: "lab", wantErr: ErrLocationExists},
+		{name: "taken by the default", old: "lab", new: "home", wantErr: ErrLocationExists},
+		{name: "unknown label", old: "beach", new: "sea", wantErr: ErrLocationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useTempHome(t)
+			addLocations(t, "home", "work", "lab")
+			if err := TagLocation(tt.old, "trip"); err != nil && tt.wantErr == nil {
+				t.Fatal(err)
+			}
+
+			err := RenameLocation(tt.old, tt.new)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if got := labels(t); !slices.Equal(got, []string{"home", "work", "lab"}) {
+					t.Errorf("a failed rename changed the labels to %v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := labels(t); !slices.Equal(got, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", got, tt.wantLabels)
+			}
+			_, def, err := ListLocations()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if def != tt.wantDefault {
+				t.Errorf("default = %q, want %q", def, tt.wantDefault)
+			}
+			loc, err := GetLocation(tt.new)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(loc.Tags, []string{"trip"}) {
+				t.Errorf("tags = %v, want them kept", loc.Tags)
+			}
+		})
+	}
+}
+
+func TestMoveLocation(t *testing.T) {
+	tests := []struct {
+		label, anchor string
+		after         bool
+		want          []string
+		wantErr       error
+	}{
+		{label: "d", anchor: "a", want: []string{"d", "a", "b", "c"}},
+		{label: "a", anchor: "d", after: true, want: []string{"b", "c", "d", "a"}},
+		{label: "a", anchor: "c", want: []string{"b", "a", "c", "d"}},
+		{label: "d", anchor: "b", after: true, want: []string{"a", "b", "d", "c"}},
+		{label: "b", anchor: "a", after: true, want: []string{"a", "b", "c", "d"}},
+		{label: "b", anchor: "b", want: []string{"a", "b", "c", "d"}},
+		{label: "x", anchor: "a", wantErr: ErrLocationNotFound},
+		{label: "a", anchor: "x", wantErr: ErrLocationNotFound},
+	}
+
+	for _, tt := range tests {
+		position := "before"
+		if tt.after {
+			position = "after"
+		}
+		t.Run(tt.label+" "+position+" "+tt.anchor, func(t *testing.T) {
+			useTempHome(t)
+			addLocations(t, "a", "b", "c", "d")
+
+			err := MoveLocation(tt.label, tt.anchor, tt.after)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := labels(t); !slices.Equal(got, tt.want) {
+				t.Errorf("order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateLocationKeepsLabelAndTags(t *testing.T) {
+	useTempHome(t)
+	addLocations(t, "home")
+	if err := TagLocation("home", "trip", "family"); err != nil {
+		t.Fatal(err)
+	}
+
+	err := UpdateLocation("home", func(loc *models.Location) error {
+		loc.Label = "changed"
+		loc.City, loc.Lat, loc.Lon = "Ankara", 39.92, 32.85
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	loc, err := GetLocation("home")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.City != "Ankara" || loc.Lat != 39.92 || !slices.Equal(loc.Tags, []string{"trip", "family"}) {
+		t.Errorf("got %+v", loc)
+	}
+
+	if err := UntagLocation("home", "trip"); err != nil {
+		t.Fatal(err)
+	}
+	if err := UntagLocation("home", "trip"); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("removing a missing tag: err = %v", err)
+	}
+	if trips, err := LocationsByTag("family"); err != nil || len(trips) != 1 {
+		t.Errorf("LocationsByTag(family) = %v, %v", trips, err)
+	}
+	if _, err := LocationsByTag("trip"); !errors.Is(err, ErrLocationNotFound) {
+		t.Errorf("LocationsByTag(trip) err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,208 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// aqiLevel is a band of an air quality index, from the previous band's
+// upper bound up to upTo
+type aqiLevel struct {
+	upTo  int
+	name  string
+	color string
+}
+
+// europeanAQILevels follows the bands of the European Environment Agency
+var europeanAQILevels = []aqiLevel{
+	{20, "Good", "\033[32m"},
+	{40, "Fair", "\033[92m"},
+	{60, "Moderate", "\033[33m"},
+	{80, "Poor", "\033[38;5;208m"},
+	{100, "Very poor", "\033[31m"},
+	{math.MaxInt, "Extremely poor", "\033[35m"},
+}
+
+// usAQILevels follows the bands of the US Environmental Protection Agency
+var usAQILevels = []aqiLevel{
+	{50, "Good", "\033[32m"},
+	{100, "Moderate", "\033[33m"},
+	{150, "Unhealthy for sensitive groups", "\033[38;5;208m"},
+	{200, "Unhealthy", "\033[31m"},
+	{300, "Very unhealthy", "\033[35m"},
+	{math.MaxInt, "Hazardous", "\033[1;31m"},
+}
+
+func aqiCategory(levels []aqiLevel, value int) aqiLevel {
+	for _, level := range levels {
+		if value <= level.upTo {
+			return level
+		}
+	}
+	return levels[len(levels)-1]
+}
+
+// aqiColor colors text in the color of its AQI band, unless colors are off
+func aqiColor(level aqiLevel, text string) string {
+	if settings.Theme == ThemeNone {
+		return text
+	}
+	return colorize(level.color, text)
+}
+
+// formatAQI formats an index with its category, and returns the function
+// coloring it. Indexes the API does not have are "n/a" and not colored.
+func formatAQI(levels []aqiLevel, value *int) (string, func(string) string) {
+	if value == nil {
+		return "n/a", func(s string) string { return s }
+	}
+	level := aqiCategory(levels, *value)
+	return fmt.Sprintf("%d %s", *value, level.name), func(s string) string { return aqiColor(level, s) }
+}
+
+// aqiCategoryName names the category of an index, "" when it is missing
+func aqiCategoryName(levels []aqiLevel, value *int) string {
+	if value == nil {
+		return ""
+	}
+	return aqiCategory(levels, *value).name
+}
+
+// pollenCount is the count of one pollen type, nil where it is not forecast
+type pollenCount struct {
+	name  string
+	value *float64
+}
+
+// pollen lists the pollen types in display order
+func pollen(current models.CurrentAirQuality) []pollenCount {
+	return []pollenCount{
+		{"Alder", current.AlderPollen},
+		{"Birch", current.BirchPollen},
+		{"Grass", current.GrassPollen},
+		{"Mugwort", current.MugwortPollen},
+		{"Olive", current.OlivePollen},
+		{"Ragweed", current.RagweedPollen},
+	}
+}
+
+// aqiLine is the line of the current weather box that summarizes the air
+// quality with the European AQI
+func aqiLine(width int, air *models.AirQuality) {
+	text, color := "Air quality: n/a", func(s string) string { return s }
+	if aqi := air.Current.EuropeanAQI; aqi != nil {
+		level := aqiCategory(europeanAQILevels, *aqi)
+		text = fmt.Sprintf("Air quality: %d (%s)", *aqi, level.name)
+		color = func(s string) string { return aqiColor(level, s) }
+	}
+	padding := max(0, width-2-textWidth(text))
+	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", padding/2), color(text), strings.Repeat(" ", padding-padding/2))
+}
+
+// DisplayAirQuality shows the air quality indexes, the pollutants and the
+// pollen counts of a location
+func DisplayAirQuality(location *models.Location, air *models.AirQuality) {
+	cityName := api.FormatCityName(location.City, location.Country, "")
+	current := air.Current
+	width := 53
+
+	// row prints a label and a value, left aligned; color is applied to the
+	// padded value
+	row := func(label, value string, color func(string) string) {
+		cell := fmt.Sprintf("%-15s%s", label, value)
+		padding := max(0, width-4-textWidth(cell))
+		fmt.Printf("│ %-15s%s%s │\n", label, color(value), strings.Repeat(" ", padding))
+	}
+	plain := func(s string) string { return s }
+	empty := func() { fmt.Println("│" + strings.Repeat(" ", width-2) + "│") }
+
+	fmt.Println("┌" + strings.Repeat("─", width-2) + "┐")
+	heading := "AIR QUALITY - " + cityName
+	padding := max(0, width-2-textWidth(heading))
+	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", padding/2), title(heading), strings.Repeat(" ", padding-padding/2))
+	fmt.Println("├" + strings.Repeat("─", width-2) + "┤")
+
+	european, color := formatAQI(europeanAQILevels, current.EuropeanAQI)
+	row("European AQI", european, color)
+	us, color := formatAQI(usAQILevels, current.USAQI)
+	row("US AQI", us, color)
+	empty()
+	row("PM2.5", fmt.Sprintf("%.1f µg/m³", current.PM2_5), plain)
+	row("PM10", fmt.Sprintf("%.1f µg/m³", current.PM10), plain)
+	row("Ozone (O3)", fmt.Sprintf("%.1f µg/m³", current.Ozone), plain)
+	row("NO2", fmt.Sprintf("%.1f µg/m³", current.NitrogenDioxide), plain)
+
+	fmt.Println("├" + strings.Repeat("─", width-2) + "┤")
+	forecast := false
+	for _, p := range pollen(current) {
+		if p.value != nil {
+			row(p.name+" pollen", fmt.Sprintf("%.1f grains/m³", *p.value), plain)
+			forecast = true
+		}
+	}
+	if !forecast {
+		row("Pollen", "not forecast here", plain)
+	}
+	fmt.Println("└" + strings.Repeat("─", width-2) + "┘")
+}
+
+// AirQualityValues is the JSON form of the current air quality
+type AirQualityValues struct {
+	Time             string             `json:"time"`
+	EuropeanAQI      *int               `json:"european_aqi"` // null where not available
+	EuropeanCategory string             `json:"european_category,omitempty"`
+	USAQI            *int               `json:"us_aqi"`
+	USCategory       string             `json:"us_category,omitempty"`
+	PM2_5            float64            `json:"pm2_5"`            // µg/m³
+	PM10             float64            `json:"pm10"`             // µg/m³
+	Ozone            float64            `json:"ozone"`            // µg/m³
+	NitrogenDioxide  float64            `json:"nitrogen_dioxide"` // µg/m³
+	Pollen           map[string]float64 `json:"pollen,omitempty"` // grains/m³ by type, where forecast
+}
+
+// AirQualityDocument is the JSON form of an air quality lookup
+type AirQualityDocument struct {
+	SchemaVersion int             `json:"schema_version"`
+	Location      models.Location `json:"location"`
+	AirQualityValues
+}
+
+// NewAirQualityValues converts an air quality API response into the stable
+// JSON schema
+func NewAirQualityValues(air *models.AirQuality) *AirQualityValues {
+	current := air.Current
+	values := &AirQualityValues{
+		Time:             current.Time,
+		EuropeanAQI:      current.EuropeanAQI,
+		EuropeanCategory: aqiCategoryName(europeanAQILevels, current.EuropeanAQI),
+		USAQI:            current.USAQI,
+		USCategory:       aqiCategoryName(usAQILevels, current.USAQI),
+		PM2_5:            current.PM2_5,
+		PM10:             current.PM10,
+		Ozone:            current.Ozone,
+		NitrogenDioxide:  current.NitrogenDioxide,
+	}
+	for _, p := range pollen(current) {
+		if p.value != nil {
+			if values.Pollen == nil {
+				values.Pollen = map[string]float64{}
+			}
+			values.Pollen[strings.ToLower(p.name)] = *p.value
+		}
+	}
+	return values
+}
+
+// NewAirQualityDocument converts an air quality API response for a location
+// into the stable JSON schema
+func NewAirQualityDocument(location *models.Location, air *models.AirQuality) AirQualityDocument {
+	return AirQualityDocument{
+		SchemaVersion:    SchemaVersion,
+		Location:         *location,
+		AirQualityValues: *NewAirQualityValues(air),
+	}
+}
@@ -0,0 +1,106 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// DashboardRow is one location of the dashboard. Weather is nil when the
+// location could not be fetched, and Err says why.
+type DashboardRow struct {
+	Location  models.Location
+	IsDefault bool
+	Weather   *models.WeatherResponse
+	Err       error
+}
+
+// DashboardDocument is the JSON form of the dashboard
+type DashboardDocument struct {
+	SchemaVersion int              `json:"schema_version"`
+	Default       string           `json:"default"`
+	Units         models.Units     `json:"units"`
+	Locations     []DashboardEntry `json:"locations"`
+}
+
+// DashboardEntry is one location of a DashboardDocument. In ndjson mode each
+// entry is printed on its own line.
+type DashboardEntry struct {
+	LocationEntry
+	Current *CurrentDocument `json:"current,omitempty"`
+	Today   *DailyDocument   `json:"today,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// NewDashboardEntry converts a dashboard row into its JSON form
+func NewDashboardEntry(row DashboardRow, units models.Units) DashboardEntry {
+	entry := DashboardEntry{LocationEntry: LocationEntry{Location: row.Location, IsDefault: row.IsDefault}}
+	if row.Err != nil {
+		entry.Error = row.Err.Error()
+		return entry
+	}
+
+	doc := NewWeatherDocument(&row.Location, row.Weather, 1, units)
+	entry.Current = &doc.Current
+	if len(doc.Daily) > 0 {
+		entry.Today = &doc.Daily[0]
+	}
+	return entry
+}
+
+// DisplayDashboard prints one table row per location with the current
+// conditions and today's range. Locations that failed show their error
+// instead.
+func DisplayDashboard(rows []DashboardRow, units models.Units) {
+	widths := []int{14, 24, 9, 22, 14, 11}
+	width := tableWidth(widths)
+
+	fmt.Println()
+	fmt.Println("┌" + strings.Repeat("─", width) + "┐")
+	fmt.Printf("│%s│\n", titleLine("WEATHER DASHBOARD", width))
+	fmt.Println(tableRule("├", "┬", "┤", widths))
+	fmt.Println(tableRow(widths, "Label", "City", "Temp", "Condition", "Wind", "Min/Max"))
+	fmt.Println(tableRule("├", "┼", "┤", widths))
+
+	for _, row := range rows {
+		label := row.Location.Label
+		if row.IsDefault {
+			label = "* " + label
+		}
+		city := api.FormatCityName(row.Location.City, row.Location.Country, "")
+
+		if row.Err != nil {
+			// The error spans the weather columns
+			rest := tableWidth(widths[2:])
+			msg := centerText(truncate("Error: "+row.Err.Error(), rest-2), rest)
+			fmt.Printf("│%s│%s│%s│\n", centerText(label, widths[0]), centerText(city, widths[1]), msg)
+			continue
+		}
+
+		current := row.Weather.CurrentWeather
+		temp := fmt.Sprintf("%.1f%s", current.Temperature, units.TemperatureSymbol())
+		condition := api.GetWeatherCodeDescription(current.Weathercode)
+		wind := fmt.Sprintf("%.0f %s %s", current.Windspeed, units.WindSymbol(), api.FormatWindDirection(current.Winddirection))
+		today := "-"
+		daily := row.Weather.Daily
+		if len(daily.TemperatureMin) > 0 && len(daily.TemperatureMax) > 0 {
+			today = fmt.Sprintf("%.0f°/%.0f°", daily.TemperatureMin[0], daily.TemperatureMax[0])
+		}
+
+		fmt.Println(tableRow(widths, label, city, temp, condition, wind, today))
+	}
+
+	fmt.Println(tableRule("└", "┴", "┘", widths))
+	fmt.Println("* = default location")
+	fmt.Println()
+}
+
+// truncate shortens text to width runes, marking the cut with an ellipsis
+func truncate(text string, width int) string {
+	if textWidth(text) <= width {
+		return text
+	}
+	return string([]rune(text)[:width-1]) + "…"
+}
@@ -4,44 +4,44 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/ugur-claw/uweather/api"
 	"github.com/ugur-claw/uweather/models"
 )
 
+// WeatherView selects what DisplayWeather shows
+type WeatherView struct {
+	Days    int  // daily rows, past days included; 1 shows the current conditions
+	Details bool // add the less common current conditions, such as pressure
+	// AirQuality adds an AQI line to the current conditions when set
+	AirQuality *models.AirQuality
+}
+
 // DisplayWeather displays weather information with ASCII art
-func DisplayWeather(location *models.Location, weather *models.WeatherResponse, days int) {
+func DisplayWeather(location *models.Location, weather *models.WeatherResponse, units models.Units, view WeatherView) {
 	cityName := api.FormatCityName(location.City, location.Country, "")
 
-	if days == 1 {
+	if view.Days == 1 {
 		// Single day display (current weather)
-		displayCurrentWeather(cityName, weather)
+		displayCurrentWeather(cityName, weather, units, view)
 	} else {
 		// Multi-day forecast - use ASCII table
-		displayForecastTable(cityName, weather, days)
+		displayForecastTable(cityName, weather, view.Days, units)
 	}
 }
 
-func displayCurrentWeather(cityName string, weather *models.WeatherResponse) {
+func displayCurrentWeather(cityName string, weather *models.WeatherResponse, units models.Units, view WeatherView) {
 	current := weather.CurrentWeather
 	art := api.GetWeatherArt(current.Weathercode)
 	desc := api.GetWeatherCodeDescription(current.Weathercode)
 	windDir := api.FormatWindDirection(current.Winddirection)
 
-	// Get humidity for current hour
-	humidity := 0
-	if len(weather.Hourly.Relativehumidity_2m) > 0 {
-		currentHour := time.Now().Hour()
-		if currentHour < len(weather.Hourly.Relativehumidity_2m) {
-			humidity = weather.Hourly.Relativehumidity_2m[currentHour]
-		}
-	}
-
 	// Calculate box width
 	width := 37
 
 	fmt.Println("┌" + strings.Repeat("─", width-2) + "┐")
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", (width-2-len(cityName))/2), cityName, strings.Repeat(" ", (width-2-len(cityName)+1)/2))
+	fmt.Printf("│%s│\n", titleLine(cityName, width-2))
 	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
 
 	// Split and display art
@@ -57,145 +57,184 @@ func displayCurrentWeather(cityName string, weather *models.WeatherResponse) {
 
 	// Weather description
 	descPadding := (width - 2 - len(desc)) / 2
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", descPadding), desc, strings.Repeat(" ", width-2-len(desc)-descPadding))
+	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", descPadding), accent(desc), strings.Repeat(" ", width-2-len(desc)-descPadding))
 
 	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
 
-	// Temperature
-	tempLine := fmt.Sprintf("Temperature: %.1f°C", current.Temperature)
-	tempPadding := (width - 2 - len(tempLine)) / 2
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", tempPadding), tempLine, strings.Repeat(" ", width-2-len(tempLine)-tempPadding))
-
-	// Wind
-	windLine := fmt.Sprintf("Wind: %.1f km/h %s", current.Windspeed, windDir)
-	windPadding := (width - 2 - len(windLine)) / 2
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", windPadding), windLine, strings.Repeat(" ", width-2-len(windLine)-windPadding))
-
-	// Humidity
-	if humidity > 0 {
-		humLine := fmt.Sprintf("Humidity: %d%%", humidity)
-		humPadding := (width - 2 - len(humLine)) / 2
-		fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", humPadding), humLine, strings.Repeat(" ", width-2-len(humLine)-humPadding))
+	boxLine(width, fmt.Sprintf("Temperature: %.1f%s", current.Temperature, units.TemperatureSymbol()))
+	boxLine(width, fmt.Sprintf("Feels like: %.1f%s", current.ApparentTemperature, units.TemperatureSymbol()))
+	boxLine(width, fmt.Sprintf("Wind: %.1f %s %s", current.Windspeed, units.WindSymbol(), windDir))
+	if current.Humidity > 0 {
+		boxLine(width, fmt.Sprintf("Humidity: %d%%", current.Humidity))
+	}
+	if view.AirQuality != nil {
+		aqiLine(width, view.AirQuality)
+	}
+
+	if view.Details {
+		fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
+		boxLine(width, fmt.Sprintf("Gusts: %.1f %s", current.WindGusts, units.WindSymbol()))
+		boxLine(width, fmt.Sprintf("Dew point: %.1f%s", current.DewPoint, units.TemperatureSymbol()))
+		boxLine(width, fmt.Sprintf("Pressure: %.0f hPa", current.SurfacePressure))
+		boxLine(width, fmt.Sprintf("Cloud cover: %d%%", current.CloudCover))
+		boxLine(width, fmt.Sprintf("UV index: %.1f", current.UVIndex))
+		boxLine(width, "Visibility: "+formatVisibility(current.Visibility, weather.CurrentUnits["visibility"]))
+		if observed, err := time.Parse("2006-01-02T15:04", current.Time); err == nil {
+			daylight := "night"
+			if current.IsDay == 1 {
+				daylight = "day"
+			}
+			boxLine(width, fmt.Sprintf("Observed: %s (%s)", formatClock(observed), daylight))
+		}
 	}
 
 	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
 	fmt.Println("└" + strings.Repeat("─", width-2) + "┘")
 }
 
-func displayForecast(cityName string, weather *models.WeatherResponse, days int) {
-	// Header
-	width := 37
-	fmt.Println("┌" + strings.Repeat("─", width-2) + "┐")
-	title := "WEATHER FORECAST"
-	titlePadding := (width - 2 - len(title)) / 2
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", titlePadding), title, strings.Repeat(" ", width-2-len(title)-titlePadding))
-
-	cityPadding := (width - 2 - len(cityName)) / 2
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", cityPadding), cityName, strings.Repeat(" ", width-2-len(cityName)-cityPadding))
-	fmt.Println("├" + strings.Repeat("─", width-2) + "┤")
-
-	// Display each day
-	daily := weather.Daily
-	for i := 0; i < days && i < len(daily.Time); i++ {
-		// Parse date
-		date, err := time.Parse("2006-01-02", daily.Time[i])
-		if err != nil {
-			continue
-		}
-
-		dayName := date.Format("Mon")
-		if i == 0 {
-			dayName = "Today"
-		} else if i == 1 {
-			dayName = "Tomorrow"
-		}
-
-		tempMax := daily.TemperatureMax[i]
-		tempMin := daily.TemperatureMin[i]
-		code := daily.Weathercode[i]
-		precip := daily.PrecipitationSum[i]
-
-		art := api.GetWeatherArt(code)
-		artLines := strings.Split(art, "\n")
-		artLine := ""
-		for _, line := range artLines {
-			trimmed := strings.TrimSpace(line)
-			if trimmed != "" {
-				artLine = trimmed
-				break
-			}
-		}
+// boxLine prints text centered in a box line of the given width
+func boxLine(width int, text string) {
+	padding := max(0, width-2-textWidth(text))
+	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", padding/2), text, strings.Repeat(" ", padding-padding/2))
+}
 
-		// Weather line
-		weatherLine := fmt.Sprintf("%s  %.0f°-%.0f°  %s", dayName, tempMin, tempMax, artLine)
-		fmt.Printf("│%s%s│\n", weatherLine, strings.Repeat(" ", width-2-len(weatherLine)))
+// titleLine centers heading in the title style on a line of the given width,
+// shortening it when it does not fit
+func titleLine(heading string, width int) string {
+	heading = truncate(heading, width)
+	padding := width - textWidth(heading)
+	return strings.Repeat(" ", padding/2) + title(heading) + strings.Repeat(" ", padding-padding/2)
+}
 
-		// Precipitation
-		if precip > 0 {
-			precipLine := fmt.Sprintf("  Rain: %.1f mm", precip)
-			fmt.Printf("│%s%s│\n", precipLine, strings.Repeat(" ", width-2-len(precipLine)))
-		}
+// formatVisibility converts a visibility in meters or feet, as reported by
+// the API, to kilometers or miles
+func formatVisibility(value float64, unit string) string {
+	switch unit {
+	case "m", "":
+		return fmt.Sprintf("%.1f km", value/1000)
+	case "ft":
+		return fmt.Sprintf("%.1f mi", value/5280)
 	}
-
-	fmt.Println("└" + strings.Repeat("─", width-2) + "┘")
+	return fmt.Sprintf("%.0f %s", value, unit)
 }
 
-func displayForecastTable(cityName string, weather *models.WeatherResponse, days int) {
-	// Header with city name
-	fmt.Println()
-	fmt.Println("┌" + strings.Repeat("─", 43) + "┐")
-	title := "WEATHER FORECAST - " + cityName
-	titlePadding := (44 - len(title)) / 2
-	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", titlePadding), title, strings.Repeat(" ", 44-len(title)-titlePadding))
-	fmt.Println("├" + strings.Repeat("─", 15) + "┬" + strings.Repeat("─", 11) + "┬" + strings.Repeat("─", 10) + "┬" + strings.Repeat("─", 6) + "┤")
-	fmt.Printf("│%s│%s│%s│%s│\n", centerText(" Day ", 15), centerText("  Temp  ", 11), centerText("  Wind  ", 10), centerText(" Status ", 6))
-	fmt.Println("├" + strings.Repeat("─", 15) + "┼" + strings.Repeat("─", 11) + "┼" + strings.Repeat("─", 10) + "┼" + strings.Repeat("─", 6) + "┤")
+// forecastPageRows is the most rows of one forecast table; longer forecasts,
+// such as 16 days plus past days, are split into tables of similar length
+const forecastPageRows = 16
+
+// displayForecastTable shows one row per day. Past days are muted and ruled
+// off from today, which is highlighted.
+func displayForecastTable(cityName string, weather *models.WeatherResponse, days int, units models.Units) {
+	daily := weather.Daily
+	rows := min(days, len(daily.Time))
+	pages, perPage := tablePages(rows)
+	today := localNow(weather).Format("2006-01-02")
 
 	// Current weather for wind info
 	current := weather.CurrentWeather
 	windSpeed := current.Windspeed
 
-	// Display each day
-	daily := weather.Daily
-	for i := 0; i < days && i < len(daily.Time); i++ {
-		// Parse date
-		date, err := time.Parse("2006-01-02", daily.Time[i])
-		if err != nil {
-			continue
+	width := tableWidth(forecastColumns)
+	for page := range pages {
+		// Header with city name
+		fmt.Println()
+		fmt.Println("┌" + strings.Repeat("─", width) + "┐")
+		heading := "WEATHER FORECAST - " + cityName
+		if pages > 1 {
+			// A long title is shortened before the page number
+			pageNumber := fmt.Sprintf(" (%d/%d)", page+1, pages)
+			heading = truncate(heading, width-textWidth(pageNumber)) + pageNumber
 		}
+		fmt.Printf("│%s│\n", titleLine(heading, width))
+		fmt.Println(tableRule("├", "┬", "┤", forecastColumns))
+		fmt.Printf("│%s│%s│%s│%s│%s│\n", centerText(" Day ", 15), centerText("  Temp  ", 11), centerText("  Rain  ", 10), centerText("  Wind  ", 10), centerText("Status", 6))
+		fmt.Println(tableRule("├", "┼", "┤", forecastColumns))
+
+		first := page * perPage
+		for i := first; i < min(first+perPage, rows); i++ {
+			// Parse date
+			date, err := time.Parse("2006-01-02", daily.Time[i])
+			if err != nil {
+				continue
+			}
 
-		dayName := date.Format("Mon Jan 2")
-		if i == 0 {
-			dayName = "Today"
-		} else if i == 1 {
-			dayName = "Tomorrow"
-		}
+			if i > first && daily.Time[i] == today && daily.Time[i-1] < today {
+				fmt.Println(tableRule("├", "┼", "┤", forecastColumns))
+			}
 
-		tempMax := daily.TemperatureMax[i]
-		tempMin := daily.TemperatureMin[i]
-		code := daily.Weathercode[i]
+			tempMax := daily.TemperatureMax[i]
+			tempMin := daily.TemperatureMin[i]
+			code := daily.Weathercode[i]
+			precip := "-"
+			if i < len(daily.PrecipitationSum) {
+				precip = fmt.Sprintf("%.1f%s", daily.PrecipitationSum[i], units.PrecipitationSymbol())
+			}
 
-		temp := fmt.Sprintf("%.0f°-%.0f°C", tempMin, tempMax)
-		wind := fmt.Sprintf("%.0fkm/h", windSpeed)
-		status := api.GetWeatherEmoji(code)
+			cells := []string{
+				centerText(" "+dayName(date, today), 15),
+				centerText(fmt.Sprintf("%.0f°-%.0f%s", tempMin, tempMax, units.TemperatureSymbol()), 11),
+				centerText(precip, 10),
+				centerText(fmt.Sprintf("%.0f%s", windSpeed, units.WindSymbol()), 10),
+				centerText(" "+api.GetWeatherEmoji(code), 6),
+			}
+			switch {
+			case daily.Time[i] < today:
+				for j := range cells[:4] {
+					cells[j] = muted(cells[j])
+				}
+			case daily.Time[i] == today:
+				cells[0] = accent(cells[0])
+			}
+			fmt.Printf("│%s│\n", strings.Join(cells, "│"))
+		}
 
-		fmt.Printf("│%s│%s│%s│%s│\n",
-			centerText(" "+dayName, 15),
-			centerText(temp, 11),
-			centerText(wind, 10),
-			centerText(" "+status, 6))
+		fmt.Println(tableRule("└", "┴", "┘", forecastColumns))
 	}
-
-	fmt.Println("└" + strings.Repeat("─", 15) + "┴" + strings.Repeat("─", 11) + "┴" + strings.Repeat("─", 10) + "┴" + strings.Repeat("─", 6) + "┘")
 	fmt.Println()
 }
 
+// tablePages splits rows into the fewest pages of at most forecastPageRows
+// rows, all about the same length
+func tablePages(rows int) (pages, perPage int) {
+	pages = max(1, (rows+forecastPageRows-1)/forecastPageRows)
+	return pages, (rows + pages - 1) / pages
+}
+
+// Column widths of the forecast and history tables
+var (
+	forecastColumns = []int{15, 11, 10, 10, 6}
+	historyColumns  = []int{15, 11, 10, 6}
+)
+
+// dayName names the days next to today and formats the others
+func dayName(date time.Time, today string) string {
+	t, err := time.Parse("2006-01-02", today)
+	if err != nil {
+		return formatDate(date)
+	}
+	switch date.Sub(t) / (24 * time.Hour) {
+	case -1:
+		return "Yesterday"
+	case 0:
+		return "Today"
+	case 1:
+		return "Tomorrow"
+	}
+	return formatDate(date)
+}
+
 func centerText(text string, width int) string {
-	padding := width - len(text)
+	padding := width - textWidth(text)
 	if padding <= 0 {
-		return text[:width]
+		return string([]rune(text)[:width])
 	}
 	left := padding / 2
 	right := padding - left
 	return strings.Repeat(" ", left) + text + strings.Repeat(" ", right)
 }
+
+// textWidth returns the number of runes in text, which matches its terminal
+// width for the Latin text and symbols used in the boxes
+func textWidth(text string) int {
+	return utf8.RuneCountInString(text)
+}
@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+func TestDisplayWeatherTitle(t *testing.T) {
+	var weather models.WeatherResponse
+	loadFixture(t, "forecast.json", &weather)
+
+	tests := []struct {
+		name      string
+		city      string
+		country   string
+		days      int
+		wantTitle string
+	}{
+		{"current", "Rio de Janeiro", "Brazil", 1, "RIO DE JANEIRO, BRAZIL"},
+		{"current long", "Llanfairpwllgwyngyllgogerychwyrndrobwll", "United Kingdom", 1, "LLANFAIRPWLLGWYNGYLLGOGERYCHWYRNDR…"},
+		{"forecast", "Rio de Janeiro", "Brazil", 3, "WEATHER FORECAST - RIO DE JANEIRO, BRAZIL"},
+		{"forecast long", "Santa Cruz de Tenerife", "Spain", 3, "WEATHER FORECAST - SANTA CRUZ DE TENERIFE, SPAIN"},
+		{"paginated long", "Llanfairpwllgwyngyllgogerychwyrndrobwll", "United Kingdom", 20, "WEATHER FORECAST - LLANFAIRPWLLGWYNGYLLGOGERYCHWY… (1/2)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := weather
+			if tt.days > len(weather.Daily.Time) {
+				// Repeat the fixture days to get a paginated table
+				for len(forecast.Daily.Time) < tt.days {
+					forecast.Daily.Time = append(forecast.Daily.Time, weather.Daily.Time...)
+					forecast.Daily.TemperatureMax = append(forecast.Daily.TemperatureMax, weather.Daily.TemperatureMax...)
+					forecast.Daily.TemperatureMin = append(forecast.Daily.TemperatureMin, weather.Daily.TemperatureMin...)
+					forecast.Daily.Weathercode = append(forecast.Daily.Weathercode, weather.Daily.Weathercode...)
+				}
+			}
+
+			location := &models.Location{City: tt.city, Country: tt.country}
+			out := captureStdout(t, func() {
+				DisplayWeather(location, &forecast, models.Metric, WeatherView{Days: tt.days})
+			})
+			if !strings.Contains(out, tt.wantTitle) {
+				t.Errorf("output lacks the title %q:\n%s", tt.wantTitle, out)
+			}
+			checkBoxWidth(t, out)
+		})
+	}
+}
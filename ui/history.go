@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// DisplayHistory shows the daily weather of a past date range, one row per
+// day, split into several tables when the range is long
+func DisplayHistory(location *models.Location, history *models.HistoricalWeather, units models.Units) {
+	cityName := api.FormatCityName(location.City, location.Country, "")
+	daily := history.Daily
+	pages, perPage := tablePages(len(daily.Time))
+	width := tableWidth(historyColumns)
+
+	for page := range pages {
+		first := page * perPage
+		last := min(first+perPage, len(daily.Time)) - 1
+
+		fmt.Println()
+		fmt.Println("┌" + strings.Repeat("─", width) + "┐")
+		heading := "WEATHER HISTORY - " + cityName
+		if pages > 1 {
+			// A long title is shortened before the page number
+			pageNumber := fmt.Sprintf(" (%d/%d)", page+1, pages)
+			heading = truncate(heading, width-textWidth(pageNumber)) + pageNumber
+		}
+		fmt.Printf("│%s│\n", titleLine(heading, width))
+		if last >= first {
+			fmt.Printf("│%s│\n", centerText(daily.Time[first]+" to "+daily.Time[last], width))
+		}
+		fmt.Println(tableRule("├", "┬", "┤", historyColumns))
+		fmt.Printf("│%s│%s│%s│%s│\n", centerText(" Day ", 15), centerText("  Temp  ", 11), centerText("  Rain  ", 10), centerText("Status", 6))
+		fmt.Println(tableRule("├", "┼", "┤", historyColumns))
+
+		for i := first; i <= last; i++ {
+			date, err := time.Parse("2006-01-02", daily.Time[i])
+			if err != nil {
+				continue
+			}
+
+			// Days the archive has no data for yet are shown as "-"
+			temp, precip, status := "-", "-", ""
+			if low, high := at(daily.TemperatureMin, i), at(daily.TemperatureMax, i); low != nil && high != nil {
+				temp = fmt.Sprintf("%.0f°-%.0f%s", *low, *high, units.TemperatureSymbol())
+			}
+			if sum := at(daily.PrecipitationSum, i); sum != nil {
+				precip = fmt.Sprintf("%.1f%s", *sum, units.PrecipitationSymbol())
+			}
+			if code := at(daily.Weathercode, i); code != nil {
+				status = api.GetWeatherEmoji(*code)
+			}
+
+			fmt.Printf("│%s│%s│%s│%s│\n",
+				centerText(" "+formatDate(date), 15),
+				centerText(temp, 11),
+				centerText(precip, 10),
+				centerText(" "+status, 6))
+		}
+
+		fmt.Println(tableRule("└", "┴", "┘", historyColumns))
+	}
+	fmt.Println()
+}
+
+// at returns values[i], or nil when values is too short
+func at[T any](values []*T, i int) *T {
+	if i < len(values) {
+		return values[i]
+	}
+	return nil
+}
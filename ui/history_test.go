@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// captureStdout returns what f prints to stdout
+func captureStdout(t *testing.T, f func()) string {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	done := make(chan []byte)
+	go func() {
+		out, _ := io.ReadAll(r)
+		done <- out
+	}()
+	f()
+	w.Close()
+	return string(<-done)
+}
+
+// checkBoxWidth fails unless every bordered line of out is as wide as the
+// first one
+func checkBoxWidth(t *testing.T, out string) {
+	t.Helper()
+	width := -1
+	for _, line := range strings.Split(out, "\n") {
+		if line == "" || !strings.ContainsAny(line[:3], "┌├│└") {
+			continue
+		}
+		if width < 0 {
+			width = textWidth(line)
+		} else if textWidth(line) != width {
+			t.Errorf("line is %d wide, want %d:\n%s", textWidth(line), width, line)
+		}
+	}
+}
+
+func TestDisplayHistoryTitle(t *testing.T) {
+	var history models.HistoricalWeather
+	loadFixture(t, "archive.json", &history)
+
+	tests := []struct {
+		city      string
+		country   string
+		wantTitle string
+	}{
+		{"Rio de Janeiro", "Brazil", "WEATHER HISTORY - RIO DE JANEIRO, BRAZIL"},
+		{"Santa Cruz de Tenerife", "Spain", "WEATHER HISTORY - SANTA CRUZ DE TENERIFE, SP…"},
+		{"Llanfairpwllgwyngyllgogerychwyrndrobwll", "United Kingdom", "WEATHER HISTORY - LLANFAIRPWLLGWYNGYLLGOGERY…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.city, func(t *testing.T) {
+			location := &models.Location{City: tt.city, Country: tt.country}
+			out := captureStdout(t, func() {
+				DisplayHistory(location, &history, models.Metric)
+			})
+			if !strings.Contains(out, tt.wantTitle) {
+				t.Errorf("output lacks the title %q:\n%s", tt.wantTitle, out)
+			}
+			checkBoxWidth(t, out)
+		})
+	}
+}
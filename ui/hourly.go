@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// sparkTicks are the block characters used by the temperature sparkline
+var sparkTicks = []rune("▁▂▃▄▅▆▇█")
+
+// sparklineWidth is the number of hours drawn per sparkline row
+const sparklineWidth = 48
+
+// DisplayHourly displays an hour-by-hour table starting at the current hour
+// of the location, with a temperature sparkline above it
+func DisplayHourly(location *models.Location, weather *models.WeatherResponse, hours int, units models.Units) {
+	cityName := api.FormatCityName(location.City, location.Country, "")
+	start, end := hourlyRange(weather, hours)
+	if start >= end {
+		fmt.Println("No hourly data available")
+		return
+	}
+
+	hourly := weather.Hourly
+	temps := hourly.Temperature_2m[start:end]
+
+	// Sparkline
+	low, high := minMax(temps)
+	fmt.Println()
+	fmt.Printf(" Temperature (%s)  min %.1f  max %.1f\n", units.TemperatureSymbol(), low, high)
+	line := sparkline(temps)
+	for len(line) > 0 {
+		n := min(sparklineWidth, len(line))
+		fmt.Println(" " + string(line[:n]))
+		line = line[n:]
+	}
+	fmt.Println()
+
+	// Table
+	widths := []int{11, 9, 6, 6, 22}
+	width := tableWidth(widths)
+
+	fmt.Println("┌" + strings.Repeat("─", width) + "┐")
+	fmt.Printf("│%s│\n", titleLine("HOURLY FORECAST - "+cityName, width))
+	fmt.Println(tableRule("├", "┬", "┤", widths))
+	fmt.Println(tableRow(widths, "Time", "Temp", "Hum", "Rain", "Weather"))
+	fmt.Println(tableRule("├", "┼", "┤", widths))
+
+	for i := start; i < end; i++ {
+		timeLabel := hourly.Time[i]
+		if t, err := time.Parse("2006-01-02T15:04", hourly.Time[i]); err == nil {
+			timeLabel = t.Format("Mon ") + formatClock(t)
+		}
+
+		temp := fmt.Sprintf("%.1f%s", hourly.Temperature_2m[i], units.TemperatureSymbol())
+		humidity := "-"
+		if i < len(hourly.Relativehumidity_2m) {
+			humidity = fmt.Sprintf("%d%%", hourly.Relativehumidity_2m[i])
+		}
+		rain := "-"
+		if i < len(hourly.PrecipitationProbability) {
+			rain = fmt.Sprintf("%d%%", hourly.PrecipitationProbability[i])
+		}
+		desc := "-"
+		if i < len(hourly.Weathercode) {
+			desc = api.GetWeatherCodeDescription(hourly.Weathercode[i])
+		}
+
+		fmt.Println(tableRow(widths, timeLabel, temp, humidity, rain, desc))
+	}
+
+	fmt.Println(tableRule("└", "┴", "┘", widths))
+	fmt.Println()
+}
+
+// HourlyDocument is one hour of a WeatherDocument
+type HourlyDocument struct {
+	Time                     string  `json:"time"`
+	Temperature              float64 `json:"temperature"`
+	Humidity                 int     `json:"humidity"`
+	PrecipitationProbability int     `json:"precipitation_probability"`
+	Weathercode              int     `json:"weathercode"`
+	Description              string  `json:"description"`
+}
+
+// NewHourlyDocuments returns the next hours of the forecast in JSON form
+func NewHourlyDocuments(weather *models.WeatherResponse, hours int) []HourlyDocument {
+	hourly := weather.Hourly
+	start, end := hourlyRange(weather, hours)
+
+	docs := []HourlyDocument{}
+	for i := start; i < end; i++ {
+		doc := HourlyDocument{Time: hourly.Time[i], Temperature: hourly.Temperature_2m[i]}
+		if i < len(hourly.Relativehumidity_2m) {
+			doc.Humidity = hourly.Relativehumidity_2m[i]
+		}
+		if i < len(hourly.PrecipitationProbability) {
+			doc.PrecipitationProbability = hourly.PrecipitationProbability[i]
+		}
+		if i < len(hourly.Weathercode) {
+			doc.Weathercode = hourly.Weathercode[i]
+			doc.Description = api.GetWeatherCodeDescription(hourly.Weathercode[i])
+		}
+		docs = append(docs, doc)
+	}
+	return docs
+}
+
+// localNow returns the current wall clock time at the forecast location
+func localNow(weather *models.WeatherResponse) time.Time {
+	return time.Now().UTC().Add(time.Duration(weather.UTCOffsetSeconds) * time.Second)
+}
+
+// currentHourIndex returns the index of the current local hour in the hourly
+// arrays: the first hour that is not over yet, or len(Time) when every hour
+// of the data is in the past
+func currentHourIndex(weather *models.WeatherResponse) int {
+	now := localNow(weather).Format("2006-01-02T15:00")
+	for i, t := range weather.Hourly.Time {
+		if t >= now {
+			return i
+		}
+	}
+	return len(weather.Hourly.Time)
+}
+
+// hourlyRange returns the [start, end) indexes of the next hours
+func hourlyRange(weather *models.WeatherResponse, hours int) (int, int) {
+	hourly := weather.Hourly
+	available := min(len(hourly.Time), len(hourly.Temperature_2m))
+	start := min(currentHourIndex(weather), available)
+	return start, min(start+hours, available)
+}
+
+// sparkline maps each value onto a block character between the lowest and
+// the highest value
+func sparkline(values []float64) []rune {
+	low, high := minMax(values)
+	line := make([]rune, len(values))
+	for i, v := range values {
+		idx := 0
+		if high > low {
+			idx = int(math.Round((v - low) / (high - low) * float64(len(sparkTicks)-1)))
+		}
+		line[i] = sparkTicks[idx]
+	}
+	return line
+}
+
+func minMax(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	low, high := values[0], values[0]
+	for _, v := range values[1:] {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	return low, high
+}
+
+// tableRule draws a horizontal table border with the given junctions
+func tableRule(left, middle, right string, widths []int) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = strings.Repeat("─", w)
+	}
+	return left + strings.Join(parts, middle) + right
+}
+
+// tableWidth is the width inside the borders of a table, the column
+// separators included
+func tableWidth(widths []int) int {
+	width := len(widths) - 1
+	for _, w := range widths {
+		width += w
+	}
+	return width
+}
+
+// tableRow draws one table row with every cell centered
+func tableRow(widths []int, cells ...string) string {
+	parts := make([]string, len(widths))
+	for i, w := range widths {
+		parts[i] = centerText(cells[i], w)
+	}
+	return "│" + strings.Join(parts, "│") + "│"
+}
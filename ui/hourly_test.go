@@ -0,0 +1,94 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// hourlyWeather returns a forecast whose hourly data starts offset hours
+// from the current local hour of a place at utcOffset and lasts n hours
+func hourlyWeather(utcOffset time.Duration, offset, n int) *models.WeatherResponse {
+	weather := &models.WeatherResponse{UTCOffsetSeconds: int(utcOffset.Seconds())}
+	start := localNow(weather).Truncate(time.Hour).Add(time.Duration(offset) * time.Hour)
+	for i := range n {
+		weather.Hourly.Time = append(weather.Hourly.Time, start.Add(time.Duration(i)*time.Hour).Format("2006-01-02T15:04"))
+		weather.Hourly.Temperature_2m = append(weather.Hourly.Temperature_2m, float64(i))
+	}
+	return weather
+}
+
+func TestHourlyRange(t *testing.T) {
+	tests := []struct {
+		name      string
+		utcOffset time.Duration
+		offset    int // first hour of the data, relative to the current hour
+		n         int
+		hours     int
+		wantStart int
+		wantEnd   int
+	}{
+		{"from yesterday", 0, -24, 72, 24, 24, 48},
+		{"east of utc, past midnight", 14 * time.Hour, -24, 72, 24, 24, 48},
+		{"west of utc, before midnight", -12 * time.Hour, -24, 72, 24, 24, 48},
+		{"half hour offset", 5*time.Hour + 30*time.Minute, -3, 10, 4, 3, 7},
+		{"starts in the future", 0, 2, 10, 4, 0, 4},
+		{"fewer hours left than asked", 0, -5, 8, 24, 5, 8},
+		{"every hour in the past", 0, -48, 24, 24, 24, 24},
+		{"no data", 0, 0, 0, 24, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weather := hourlyWeather(tt.utcOffset, tt.offset, tt.n)
+			start, end := hourlyRange(weather, tt.hours)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("hourlyRange = [%d, %d), want [%d, %d)", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestHourlyRangeShortTemperatures(t *testing.T) {
+	weather := hourlyWeather(0, -2, 24)
+	weather.Hourly.Temperature_2m = weather.Hourly.Temperature_2m[:5]
+
+	if start, end := hourlyRange(weather, 24); start != 2 || end != 5 {
+		t.Errorf("hourlyRange = [%d, %d), want [2, 5)", start, end)
+	}
+}
+
+func TestDisplayHourlyStaleData(t *testing.T) {
+	weather := hourlyWeather(0, -48, 24)
+	out := captureStdout(t, func() {
+		DisplayHourly(&models.Location{City: "Oslo", Country: "Norway"}, weather, 12, models.Metric)
+	})
+	if strings.TrimSpace(out) != "No hourly data available" {
+		t.Errorf("stale data was shown:\n%s", out)
+	}
+	if docs := NewHourlyDocuments(weather, 12); len(docs) != 0 {
+		t.Errorf("got %d hourly documents for stale data", len(docs))
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{5}, "▁"},
+		{[]float64{3, 3, 3}, "▁▁▁"},
+		{[]float64{1, 2, 3}, "▁▅█"},
+		{[]float64{-7, 0, 7}, "▁▅█"},
+		{[]float64{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+	}
+
+	for _, tt := range tests {
+		if got := string(sparkline(tt.values)); got != tt.want {
+			t.Errorf("sparkline(%v) = %q, want %q", tt.values, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// SchemaVersion is bumped whenever a field of the JSON documents below is
+// renamed, removed or changes meaning. Adding fields does not bump it.
+const SchemaVersion = 1
+
+// WeatherDocument is the stable JSON form of a weather lookup
+type WeatherDocument struct {
+	SchemaVersion int               `json:"schema_version"`
+	Location      models.Location   `json:"location"`
+	Units         models.Units      `json:"units"`
+	Current       CurrentDocument   `json:"current"`
+	Daily         []DailyDocument   `json:"daily"`
+	Hourly        []HourlyDocument  `json:"hourly,omitempty"`
+	AirQuality    *AirQualityValues `json:"air_quality,omitempty"`
+}
+
+// CurrentDocument holds the current conditions of a WeatherDocument
+type CurrentDocument struct {
+	Time                string  `json:"time"`
+	Temperature         float64 `json:"temperature"`
+	ApparentTemperature float64 `json:"apparent_temperature"`
+	Humidity            int     `json:"humidity"`
+	DewPoint            float64 `json:"dew_point"`
+	SurfacePressure     float64 `json:"surface_pressure"` // hPa
+	CloudCover          int     `json:"cloud_cover"`      // %
+	Windspeed           float64 `json:"windspeed"`
+	Winddirection       float64 `json:"winddirection"`
+	WindGusts           float64 `json:"wind_gusts"`
+	UVIndex             float64 `json:"uv_index"`
+	Visibility          float64 `json:"visibility"` // meters, or feet with imperial precipitation units
+	Weathercode         int     `json:"weathercode"`
+	Description         string  `json:"description"`
+	IsDay               bool    `json:"is_day"`
+}
+
+// DailyDocument is one forecast day of a WeatherDocument
+type DailyDocument struct {
+	Date             string  `json:"date"`
+	TemperatureMax   float64 `json:"temperature_max"`
+	TemperatureMin   float64 `json:"temperature_min"`
+	Weathercode      int     `json:"weathercode"`
+	Description      string  `json:"description"`
+	PrecipitationSum float64 `json:"precipitation_sum"`
+	Past             bool    `json:"past,omitempty"` // the day is over; the values are observed
+}
+
+// HistoryDocument is the JSON form of a historical weather lookup
+type HistoryDocument struct {
+	SchemaVersion int                  `json:"schema_version"`
+	Location      models.Location      `json:"location"`
+	Units         models.Units         `json:"units"`
+	From          string               `json:"from"`
+	To            string               `json:"to"`
+	Daily         []HistoryDayDocument `json:"daily"`
+}
+
+// HistoryDayDocument is the JSON form of one day of the archive. Values the
+// archive does not have yet are left out.
+type HistoryDayDocument struct {
+	Date             string   `json:"date"`
+	TemperatureMax   *float64 `json:"temperature_max,omitempty"`
+	TemperatureMin   *float64 `json:"temperature_min,omitempty"`
+	Weathercode      *int     `json:"weathercode,omitempty"`
+	Description      string   `json:"description,omitempty"`
+	PrecipitationSum *float64 `json:"precipitation_sum,omitempty"`
+	Past             bool     `json:"past"`
+}
+
+// NewHistoryDocument converts an archive API response into the stable JSON
+// schema
+func NewHistoryDocument(location *models.Location, history *models.HistoricalWeather, from, to string, units models.Units) HistoryDocument {
+	return HistoryDocument{
+		SchemaVersion: SchemaVersion,
+		Location:      *location,
+		Units:         units,
+		From:          from,
+		To:            to,
+		Daily:         historyDayDocuments(history.Daily),
+	}
+}
+
+func historyDayDocuments(daily models.HistoricalDaily) []HistoryDayDocument {
+	docs := []HistoryDayDocument{}
+	for i, date := range daily.Time {
+		day := HistoryDayDocument{
+			Date:             date,
+			TemperatureMax:   at(daily.TemperatureMax, i),
+			TemperatureMin:   at(daily.TemperatureMin, i),
+			Weathercode:      at(daily.Weathercode, i),
+			PrecipitationSum: at(daily.PrecipitationSum, i),
+			Past:             true,
+		}
+		if day.Weathercode != nil {
+			day.Description = api.GetWeatherCodeDescription(*day.Weathercode)
+		}
+		docs = append(docs, day)
+	}
+	return docs
+}
+
+// LocationsDocument is the JSON form of the saved locations list
+type LocationsDocument struct {
+	SchemaVersion int             `json:"schema_version"`
+	Default       string          `json:"default"`
+	Locations     []LocationEntry `json:"locations"`
+}
+
+// LocationEntry is a saved location flagged with its default status. In
+// ndjson mode each entry is printed on its own line.
+type LocationEntry struct {
+	models.Location
+	IsDefault bool `json:"is_default"`
+}
+
+// LocationDocument reports a location that a command added or removed
+type LocationDocument struct {
+	SchemaVersion int             `json:"schema_version"`
+	Action        string          `json:"action"`
+	Location      models.Location `json:"location"`
+}
+
+// GroupDocument is the JSON form of the weather of several locations, in
+// their saved order. Locations that could not be fetched are listed in Errors.
+type GroupDocument struct {
+	SchemaVersion int               `json:"schema_version"`
+	Group         string            `json:"group,omitempty"`
+	Locations     []WeatherDocument `json:"locations"`
+	Errors        []LocationError   `json:"errors,omitempty"`
+}
+
+// LocationError reports a location of a GroupDocument that failed
+type LocationError struct {
+	Label string `json:"label"`
+	Error string `json:"error"`
+}
+
+// ErrorDocument is written to stderr when a command fails in a JSON mode
+type ErrorDocument struct {
+	SchemaVersion int                      `json:"schema_version"`
+	Label         string                   `json:"label,omitempty"` // location of a group that failed
+	Error         string                   `json:"error"`
+	Code          string                   `json:"code"`
+	ExitCode      int                      `json:"exit_code"`
+	Candidates    []models.GeocodingResult `json:"candidates,omitempty"`
+	Status        int                      `json:"status,omitempty"` // HTTP status of an API error
+	Reason        string                   `json:"reason,omitempty"` // reason given by the API
+}
+
+// NewWeatherDocument converts an API response into the stable JSON schema.
+// days counts the daily entries, past days included.
+func NewWeatherDocument(location *models.Location, weather *models.WeatherResponse, days int, units models.Units) WeatherDocument {
+	current := weather.CurrentWeather
+	doc := WeatherDocument{
+		SchemaVersion: SchemaVersion,
+		Location:      *location,
+		Units:         units,
+		Current: CurrentDocument{
+			Time:                current.Time,
+			Temperature:         current.Temperature,
+			ApparentTemperature: current.ApparentTemperature,
+			Humidity:            current.Humidity,
+			DewPoint:            current.DewPoint,
+			SurfacePressure:     current.SurfacePressure,
+			CloudCover:          current.CloudCover,
+			Windspeed:           current.Windspeed,
+			Winddirection:       current.Winddirection,
+			WindGusts:           current.WindGusts,
+			UVIndex:             current.UVIndex,
+			Visibility:          current.Visibility,
+			Weathercode:         current.Weathercode,
+			Description:         api.GetWeatherCodeDescription(current.Weathercode),
+			IsDay:               current.IsDay == 1,
+		},
+		Daily: dailyDocuments(weather.Daily, days, localNow(weather).Format("2006-01-02")),
+	}
+
+	return doc
+}
+
+// dailyDocuments converts the first days of daily; the days before today are
+// flagged as past
+func dailyDocuments(daily models.DailyWeather, days int, today string) []DailyDocument {
+	docs := []DailyDocument{}
+	for i := 0; i < days && i < len(daily.Time); i++ {
+		day := DailyDocument{Date: daily.Time[i], Past: daily.Time[i] < today}
+		if i < len(daily.TemperatureMax) {
+			day.TemperatureMax = daily.TemperatureMax[i]
+		}
+		if i < len(daily.TemperatureMin) {
+			day.TemperatureMin = daily.TemperatureMin[i]
+		}
+		if i < len(daily.Weathercode) {
+			day.Weathercode = daily.Weathercode[i]
+			day.Description = api.GetWeatherCodeDescription(daily.Weathercode[i])
+		}
+		if i < len(daily.PrecipitationSum) {
+			day.PrecipitationSum = daily.PrecipitationSum[i]
+		}
+		docs = append(docs, day)
+	}
+	return docs
+}
+
+// PrintJSON writes v to stdout, indented for json and on a single line for
+// ndjson
+func PrintJSON(v any, ndjson bool) error {
+	return WriteJSON(os.Stdout, v, ndjson)
+}
+
+// WriteJSON writes v to w, indented unless ndjson is set
+func WriteJSON(w io.Writer, v any, ndjson bool) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if !ndjson {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return nil
+}
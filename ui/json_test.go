@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ugur-claw/uweather/api/apitest"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// loadFixture decodes a recorded API response into v
+func loadFixture(t *testing.T, name string, v any) {
+	t.Helper()
+	data, err := apitest.Fixture(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+}
+
+// encode writes doc with WriteJSON and decodes it back into a generic map
+func encode(t *testing.T, doc any, ndjson bool) (string, map[string]any) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteJSON(&buf, doc, ndjson); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, buf.String())
+	}
+	return buf.String(), decoded
+}
+
+var testLocation = &models.Location{Label: "home", City: "Istanbul", Country: "Türkiye", Lat: 41.01, Lon: 28.95}
+
+func TestWeatherDocument(t *testing.T) {
+	var weather models.WeatherResponse
+	loadFixture(t, "forecast.json", &weather)
+
+	_, doc := encode(t, NewWeatherDocument(testLocation, &weather, 3, models.Metric), false)
+
+	if doc["schema_version"] != float64(SchemaVersion) {
+		t.Errorf("schema_version = %v, want %d", doc["schema_version"], SchemaVersion)
+	}
+	location := doc["location"].(map[string]any)
+	if location["label"] != "home" || location["city"] != "Istanbul" {
+		t.Errorf("location = %v", location)
+	}
+	units := doc["units"].(map[string]any)
+	if units["temperature"] != "celsius" || units["precipitation"] != "mm" {
+		t.Errorf("units = %v", units)
+	}
+
+	current := doc["current"].(map[string]any)
+	for key, want := range map[string]any{
+		"temperature": 18.4,
+		"humidity":    float64(64),
+		"weathercode": float64(2),
+		"description": "Partly cloudy",
+		"is_day":      true,
+	} {
+		if current[key] != want {
+			t.Errorf("current.%s = %v, want %v", key, current[key], want)
+		}
+	}
+
+	daily := doc["daily"].([]any)
+	if len(daily) != 3 {
+		t.Fatalf("got %d days, want 3", len(daily))
+	}
+	for _, key := range []string{"date", "temperature_max", "temperature_min", "weathercode", "description", "precipitation_sum"} {
+		if _, ok := daily[0].(map[string]any)[key]; !ok {
+			t.Errorf("daily[0] lacks %s", key)
+		}
+	}
+	if _, ok := doc["hourly"]; ok {
+		t.Error("hourly is present without --hourly")
+	}
+	if _, ok := doc["air_quality"]; ok {
+		t.Error("air_quality is present without --aqi")
+	}
+}
+
+func TestWriteJSONNDJSON(t *testing.T) {
+	var weather models.WeatherResponse
+	loadFixture(t, "forecast.json", &weather)
+
+	out, _ := encode(t, NewWeatherDocument(testLocation, &weather, 1, models.Metric), true)
+	if strings.Count(out, "\n") != 1 || !strings.HasSuffix(out, "\n") {
+		t.Errorf("ndjson output is not a single line:\n%s", out)
+	}
+}
+
+func TestAirQualityDocument(t *testing.T) {
+	var air models.AirQuality
+	loadFixture(t, "air_quality.json", &air)
+
+	_, doc := encode(t, NewAirQualityDocument(testLocation, &air), false)
+	if doc["european_category"] == nil || doc["us_category"] == nil {
+		t.Errorf("categories missing: %v", doc)
+	}
+
+	// A missing index is null, without a category
+	air.Current.EuropeanAQI = nil
+	_, doc = encode(t, NewAirQualityDocument(testLocation, &air), false)
+	if value, ok := doc["european_aqi"]; !ok || value != nil {
+		t.Errorf("european_aqi = %v, want null", value)
+	}
+	if _, ok := doc["european_category"]; ok {
+		t.Errorf("european_category = %v, want it left out", doc["european_category"])
+	}
+}
+
+func TestHistoryDocument(t *testing.T) {
+	var history models.HistoricalWeather
+	loadFixture(t, "archive.json", &history)
+
+	// The archive answers null for the days it has no data for yet
+	history.Daily.TemperatureMax[4] = nil
+	history.Daily.TemperatureMin[4] = nil
+	history.Daily.Weathercode[4] = nil
+	history.Daily.PrecipitationSum[4] = nil
+
+	_, doc := encode(t, NewHistoryDocument(testLocation, &history, "2024-03-10", "2024-03-14", models.Metric), false)
+	daily := doc["daily"].([]any)
+	if len(daily) != 5 {
+		t.Fatalf("got %d days, want 5", len(daily))
+	}
+	first := daily[0].(map[string]any)
+	if first["temperature_max"] != 11.2 || first["description"] != "Overcast" {
+		t.Errorf("daily[0] = %v", first)
+	}
+	last := daily[4].(map[string]any)
+	for _, key := range []string{"temperature_max", "temperature_min", "weathercode", "description", "precipitation_sum"} {
+		if _, ok := last[key]; ok {
+			t.Errorf("daily[4].%s = %v, want it left out", key, last[key])
+		}
+	}
+}
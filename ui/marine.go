@@ -0,0 +1,165 @@
+package ui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/ugur-claw/uweather/api"
+	"github.com/ugur-claw/uweather/models"
+)
+
+// waveArt is drawn in the marine box in place of the weather art
+const waveArt = `    .-~~-.      .-~~-.
+ ~-'      '-~~-'      '-~
+   ~~   ~~~    ~~   ~~~  `
+
+// seaState is a band of the Douglas sea scale, from the previous band's upper
+// bound up to upTo meters of wave height
+type seaState struct {
+	upTo float64
+	name string
+}
+
+var seaStates = []seaState{
+	{0.1, "Calm sea"},
+	{0.5, "Smooth sea"},
+	{1.25, "Slight sea"},
+	{2.5, "Moderate sea"},
+	{4, "Rough sea"},
+	{6, "Very rough sea"},
+	{9, "High sea"},
+	{14, "Very high sea"},
+	{math.Inf(1), "Phenomenal sea"},
+}
+
+// seaStateName describes a wave height reported in unit, "m" or "ft"
+func seaStateName(height float64, unit string) string {
+	if unit == "ft" {
+		height *= 0.3048
+	}
+	for _, state := range seaStates {
+		if height <= state.upTo {
+			return state.name
+		}
+	}
+	return seaStates[len(seaStates)-1].name
+}
+
+// marineUnit is the unit of a marine variable as reported by the API, with
+// the default the API uses when it is missing
+func marineUnit(marine *models.Marine, variable, fallback string) string {
+	if unit := marine.CurrentUnits[variable]; unit != "" {
+		return unit
+	}
+	return fallback
+}
+
+// seaTemperature converts a sea surface temperature, always reported in
+// Celsius, to the temperature unit of units
+func seaTemperature(celsius float64, units models.Units) float64 {
+	if units.Temperature == "fahrenheit" {
+		return celsius*9/5 + 32
+	}
+	return celsius
+}
+
+// DisplayMarine shows the current waves, swell and sea temperature of a
+// location in the box of the current weather
+func DisplayMarine(location *models.Location, marine *models.Marine, units models.Units) {
+	cityName := api.FormatCityName(location.City, location.Country, "")
+	current := marine.Current
+	length := marineUnit(marine, "wave_height", "m")
+	width := 37
+
+	fmt.Println("┌" + strings.Repeat("─", width-2) + "┐")
+	padding := max(0, width-2-textWidth(cityName))
+	fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", padding/2), title(cityName), strings.Repeat(" ", padding-padding/2))
+	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
+
+	for _, line := range strings.Split(waveArt, "\n") {
+		boxLine(width, strings.TrimRight(line, " "))
+	}
+
+	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
+	if current.WaveHeight != nil {
+		desc := seaStateName(*current.WaveHeight, length)
+		padding := max(0, width-2-textWidth(desc))
+		fmt.Printf("│%s%s%s│\n", strings.Repeat(" ", padding/2), accent(desc), strings.Repeat(" ", padding-padding/2))
+	}
+	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
+
+	if current.WaveHeight != nil {
+		line := fmt.Sprintf("Waves: %.1f %s", *current.WaveHeight, length)
+		if current.WaveDirection != nil {
+			line += " " + api.FormatWindDirection(*current.WaveDirection)
+		}
+		boxLine(width, line)
+	}
+	if current.WavePeriod != nil {
+		boxLine(width, fmt.Sprintf("Period: %.1f s", *current.WavePeriod))
+	}
+	if current.SwellWaveHeight != nil {
+		line := fmt.Sprintf("Swell: %.1f %s", *current.SwellWaveHeight, length)
+		if current.SwellWaveDirection != nil {
+			line += " " + api.FormatWindDirection(*current.SwellWaveDirection)
+		}
+		if current.SwellWavePeriod != nil {
+			line += fmt.Sprintf(", %.0f s", *current.SwellWavePeriod)
+		}
+		boxLine(width, line)
+	}
+	if current.WindWaveHeight != nil {
+		boxLine(width, fmt.Sprintf("Wind waves: %.1f %s", *current.WindWaveHeight, length))
+	}
+	if current.SeaSurfaceTemperature != nil {
+		boxLine(width, fmt.Sprintf("Sea: %.1f%s", seaTemperature(*current.SeaSurfaceTemperature, units), units.TemperatureSymbol()))
+	}
+
+	fmt.Println("│" + strings.Repeat(" ", width-2) + "│")
+	fmt.Println("└" + strings.Repeat("─", width-2) + "┘")
+}
+
+// MarineDocument is the JSON form of a marine lookup
+type MarineDocument struct {
+	SchemaVersion         int             `json:"schema_version"`
+	Location              models.Location `json:"location"`
+	Time                  string          `json:"time"`
+	LengthUnit            string          `json:"length_unit"` // "m" or "ft"
+	SeaState              string          `json:"sea_state"`
+	WaveHeight            *float64        `json:"wave_height"`
+	WaveDirection         *float64        `json:"wave_direction,omitempty"` // degrees
+	WavePeriod            *float64        `json:"wave_period,omitempty"`    // seconds
+	SwellWaveHeight       *float64        `json:"swell_wave_height,omitempty"`
+	SwellWaveDirection    *float64        `json:"swell_wave_direction,omitempty"`
+	SwellWavePeriod       *float64        `json:"swell_wave_period,omitempty"`
+	WindWaveHeight        *float64        `json:"wind_wave_height,omitempty"`
+	SeaSurfaceTemperature *float64        `json:"sea_surface_temperature,omitempty"` // in the temperature unit
+}
+
+// NewMarineDocument converts a marine API response for a location into the
+// stable JSON schema
+func NewMarineDocument(location *models.Location, marine *models.Marine, units models.Units) MarineDocument {
+	current := marine.Current
+	doc := MarineDocument{
+		SchemaVersion:      SchemaVersion,
+		Location:           *location,
+		Time:               current.Time,
+		LengthUnit:         marineUnit(marine, "wave_height", "m"),
+		WaveHeight:         current.WaveHeight,
+		WaveDirection:      current.WaveDirection,
+		WavePeriod:         current.WavePeriod,
+		SwellWaveHeight:    current.SwellWaveHeight,
+		SwellWaveDirection: current.SwellWaveDirection,
+		SwellWavePeriod:    current.SwellWavePeriod,
+		WindWaveHeight:     current.WindWaveHeight,
+	}
+	if current.WaveHeight != nil {
+		doc.SeaState = seaStateName(*current.WaveHeight, doc.LengthUnit)
+	}
+	if current.SeaSurfaceTemperature != nil {
+		temperature := seaTemperature(*current.SeaSurfaceTemperature, units)
+		doc.SeaSurfaceTemperature = &temperature
+	}
+	return doc
+}
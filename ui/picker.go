@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ugur-claw/uweather/models"
+)
+
+// IsInteractive reports whether stdin is a terminal the user can answer on
+func IsInteractive() bool {
+	return isTerminal(os.Stdin)
+}
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	// /dev/null is a character device too, but nobody is there to answer
+	if null, err := os.Stat(os.DevNull); err == nil && os.SameFile(info, null) {
+		return false
+	}
+	return true
+}
+
+// FormatCandidate describes a geocoding match on one line, e.g.
+// "Paris, Texas, United States (pop. 24,782) 33.6609, -95.5555"
+func FormatCandidate(r models.GeocodingResult) string {
+	parts := []string{r.Name}
+	if r.Admin1 != "" && r.Admin1 != r.Name {
+		parts = append(parts, r.Admin1)
+	}
+	if r.Country != "" {
+		parts = append(parts, r.Country)
+	}
+
+	line := strings.Join(parts, ", ")
+	if r.Population > 0 {
+		line += fmt.Sprintf(" (pop. %s)", formatThousands(r.Population))
+	}
+	return line + fmt.Sprintf(" %.4f, %.4f", r.Latitude, r.Longitude)
+}
+
+// PickCandidate shows a numbered list of matches on stderr and reads the
+// user's choice from stdin. It returns the 0-based index of the choice.
+func PickCandidate(query string, results []models.GeocodingResult) (int, error) {
+	fmt.Fprintf(os.Stderr, "Multiple matches for %q:\n", query)
+	for i, r := range results {
+		fmt.Fprintf(os.Stderr, "  %d) %s\n", i+1, FormatCandidate(r))
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Fprintf(os.Stderr, "Select a location [1-%d]: ", len(results))
+		line, err := reader.ReadString('\n')
+		choice, convErr := strconv.Atoi(strings.TrimSpace(line))
+		if convErr == nil && choice >= 1 && choice <= len(results) {
+			return choice - 1, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("no location selected")
+		}
+		fmt.Fprintf(os.Stderr, "Please enter a number between 1 and %d\n", len(results))
+	}
+}
+
+// formatThousands formats n with comma separators
+func formatThousands(n int) string {
+	s := strconv.Itoa(n)
+	if n < 0 {
+		return "-" + formatThousands(-n)
+	}
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"os"
+	"time"
+)
+
+// Date formats accepted by the "date_format" preference
+const (
+	DateShort = "short"
+	DateISO   = "iso"
+	DateEU    = "eu"
+	DateUS    = "us"
+)
+
+// Time formats accepted by the "time_format" preference
+const (
+	Time24h = "24h"
+	Time12h = "12h"
+)
+
+// Themes accepted by the "theme" preference
+const (
+	ThemeAuto  = "auto"
+	ThemeDark  = "dark"
+	ThemeLight = "light"
+	ThemeNone  = "none"
+)
+
+var (
+	DateFormats = []string{DateShort, DateISO, DateEU, DateUS}
+	TimeFormats = []string{Time24h, Time12h}
+	Themes      = []string{ThemeAuto, ThemeDark, ThemeLight, ThemeNone}
+)
+
+var dateLayouts = map[string]string{
+	DateShort: "Mon Jan 2",
+	DateISO:   "2006-01-02",
+	DateEU:    "02.01.2006",
+	DateUS:    "01/02/2006",
+}
+
+var clockLayouts = map[string]string{
+	Time24h: "15:04",
+	Time12h: "3:04 PM",
+}
+
+// palette holds the ANSI escape sequences of a theme
+type palette struct {
+	title, accent, muted string
+}
+
+var palettes = map[string]palette{
+	ThemeDark:  {title: "\033[1;96m", accent: "\033[93m", muted: "\033[90m"},
+	ThemeLight: {title: "\033[1;34m", accent: "\033[35m", muted: "\033[90m"},
+}
+
+const ansiReset = "\033[0m"
+
+// Settings controls how dates, times and colors are rendered
+type Settings struct {
+	DateFormat string
+	TimeFormat string
+	Theme      string
+}
+
+var settings = Settings{DateFormat: DateShort, TimeFormat: Time24h, Theme: ThemeNone}
+
+// Configure applies the display settings; empty fields keep their defaults.
+// The auto theme uses colors only when stdout is a terminal and NO_COLOR is
+// not set.
+func Configure(s Settings) {
+	if s.DateFormat != "" {
+		settings.DateFormat = s.DateFormat
+	}
+	if s.TimeFormat != "" {
+		settings.TimeFormat = s.TimeFormat
+	}
+
+	settings.Theme = s.Theme
+	if settings.Theme == "" || settings.Theme == ThemeAuto {
+		settings.Theme = ThemeNone
+		if os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout) {
+			settings.Theme = ThemeDark
+		}
+	}
+}
+
+// formatDate formats a day in the configured date format
+func formatDate(t time.Time) string {
+	return t.Format(dateLayouts[settings.DateFormat])
+}
+
+// formatClock formats a time of day in the configured time format
+func formatClock(t time.Time) string {
+	return t.Format(clockLayouts[settings.TimeFormat])
+}
+
+// title colors a box title; padding must be computed before coloring
+func title(text string) string {
+	return colorize(palettes[settings.Theme].title, text)
+}
+
+// accent colors a highlighted value; padding must be computed before coloring
+func accent(text string) string {
+	return colorize(palettes[settings.Theme].accent, text)
+}
+
+// muted colors secondary text such as past days; padding must be computed
+// before coloring
+func muted(text string) string {
+	return colorize(palettes[settings.Theme].muted, text)
+}
+
+func colorize(code, text string) string {
+	if code == "" {
+		return text
+	}
+	return code + text + ansiReset
+}